@@ -22,6 +22,13 @@ func Delete(sql string) string {
 	panic(tip)
 }
 
+// Timeout 指定mapper方法执行sql的超时时间, 与Select、Insert、Update、Delete一起使用
+// 参数需要为常量表达式, 如Timeout(500 * time.Millisecond)
+// 超时后返回的错误可以通过errors.Is(err, vulcan.ErrTimeout)判断
+func Timeout(timeout time.Duration) {
+	panic(tip)
+}
+
 func Cacheable(key string, cacheNil bool, queryTimeOut time.Duration) {
 	panic(tip)
 }
@@ -85,6 +92,8 @@ func Choose() chooseStmt {
 // AddBatch: 批量新增
 // DeleteById: 根据主键Id删除
 // DeleteBatchIds: 根据主键Id列表删除
+// HardDeleteById: 根据主键Id物理删除, 用于有逻辑删除字段的模型
+// HardDeleteBatchIds: 根据主键Id列表物理删除, 用于有逻辑删除字段的模型
 // SelectById: 根据主键Id查询
 // SelectBatchIds: 根据主键Id列表查询
 // SelectAll: 查询全部
@@ -98,6 +107,7 @@ func Choose() chooseStmt {
 //  查询或更新条件参数：该参数用于指定查询时查询出哪些列或者更新时更新哪些列, 使用[], 中括号内部指定索引或名称
 //  查询时或更新时是否判空：该参数为一个bool字面量, 用于指定在查询或更新时是否需要对字段进行判空, 如果为空则不作为查询或更新条件
 // DeleteBy[2&6]: 根据Where条件参数进行删除
+// HardDeleteBy[2&6]: 根据Where条件参数进行物理删除
 //
 // UpdateById[2-4,6]: 根据Id更新指定的列
 // UpdateByXXX[2,4,6][1]: 根据Where条件参数更新指定的列, 其中XXX后缀可以由用户任意指定, 第一个参数为Where条件参数, 第二个参数为要更新的列
@@ -120,4 +130,53 @@ func Choose() chooseStmt {
 //			type User struct {
 //			    vulcan.TableProperty `gen:"Add,DeleteById,UpdateById([2-4], true),GetById"`
 //		 	}
+//
+// 3、使用sensitive指定敏感列, 多个列使用逗号分隔, 也可以在字段的db标签中添加sensitive选项
+// 敏感列的参数在sql调试日志、慢查询日志等记录中会被替换为vulcan.MaskedValue
+//
+//			type User struct {
+//			    vulcan.TableProperty `tableName:"t_user" sensitive:"email"`
+//				Password string  `db:"password,sensitive"`
+//				Email    string  `db:"email"`
+//		 	}
+//
+// 4、使用version指定乐观锁版本列, 也可以在字段的db标签中添加version选项, 版本字段必须为整数类型
+// 生成的UpdateById、UpdateByXXX函数会添加version=version+1以及AND version=#{...}条件
+// 更新其它带有版本字段的结构体时, 执行sql前会自动添加版本条件, 没有更新任何行时返回vulcan.ErrOptimisticLock, 更新成功后结构体中的版本号加1
+// 只有sql操作的表与tableName指定的表相同时才会使用乐观锁
+//
+//			type User struct {
+//			    vulcan.TableProperty `tableName:"t_user" version:"version"`
+//				Version  int64  `db:"version"`
+//		 	}
+//
+// 5、使用logicDelete指定逻辑删除列, 也可以在字段的db标签中添加logic_delete选项, 逻辑删除字段必须为可空类型, NULL表示没有删除
+// 生成的DeleteById、DeleteBatchIds、DeleteBy函数会更新该列为CURRENT_TIMESTAMP, 需要物理删除时使用HardDeleteXXX函数
+// 查询该表的sql执行时会自动过滤已经删除的行, 使用vulcan.IncludeDeleted()可以查询已经删除的行
+//
+//			type User struct {
+//			    vulcan.TableProperty `tableName:"t_user" logicDelete:"deleted_at"`
+//				DeletedAt sql.NullTime `db:"deleted_at"`
+//		 	}
+//
+// 6、使用fillInsert、fillUpdate、fillInsertUpdate指定插入时、更新时、插入和更新时自动填充的列
+// 也可以在字段的db标签中添加fill_insert、fill_update、fill_insert_update选项
+// 生成的代码在绑定参数之前使用vulcan.MetaFillHandler填充这些字段, 插入时只填充零值字段, 更新时总是覆盖
+// 默认的处理器为以_at结尾的列填充当前时间, 以_by结尾的列填充vulcan.WithUser设置的用户, 可以通过vulcan.SetMetaFillHandler替换
+// 生成的UpdateById、UpdateByXXX函数总是更新更新时需要填充的列
+//
+//			type User struct {
+//			    vulcan.TableProperty `tableName:"t_user" fillInsert:"created_at,created_by" fillInsertUpdate:"updated_at,updated_by"`
+//				CreatedAt time.Time `db:"created_at"`
+//				UpdatedAt time.Time `db:"updated_at"`
+//				CreatedBy int64     `db:"created_by"`
+//				UpdatedBy int64     `db:"updated_by"`
+//		 	}
+//
+// 7、使用timeout指定该表的sql默认的超时时间, 格式与time.ParseDuration相同
+// 文件中操作该表并且没有使用Timeout注解的mapper方法使用该超时时间, 都没有指定时使用vulcan.SetDefaultTimeout设置的默认值
+//
+//			type User struct {
+//			    vulcan.TableProperty `tableName:"t_user" timeout:"500ms"`
+//		 	}
 type TableProperty struct{}
@@ -59,17 +59,27 @@ type result struct {
 	err  error
 }
 
+// 获取本次执行使用的缓存key, sql经过多租户改写时加入租户, 防止不同租户读取到相同的缓存
+func (c *CacheConfig[T]) key(option *ExecOption) string {
+	if option.tenant == nil {
+		return c.Key
+	}
+
+	return fmt.Sprintf("%s:tenant:%v", c.Key, option.tenant)
+}
+
 func cacheableHandler[T any](cfg *CacheConfig[T], option *ExecOption, next Handler) (*T, error) {
 	if cfg.Key == "" {
 		return nil, fmt.Errorf("empty key provided")
 	}
+	key := cfg.key(option)
 
 	// 1、查询缓存
-	if val, exist := cfg.Manager.Get(cfg.Key); exist {
+	if val, exist := cfg.Manager.Get(key); exist {
 		return val, nil
 	}
 
-	v, err, _ := cfg.flightGroup.Do(cfg.Key, func() (any, error) {
+	v, err, _ := cfg.flightGroup.Do(key, func() (any, error) {
 		ctx := context.Background()
 		if cfg.QueryTimeOut > 0 {
 			c, cancel := context.WithTimeout(ctx, cfg.QueryTimeOut)
@@ -107,13 +117,13 @@ func cacheableHandler[T any](cfg *CacheConfig[T], option *ExecOption, next Handl
 			}
 
 			// 3、写入缓存
-			cfg.Manager.Set(cfg.Key, objPtr)
+			cfg.Manager.Set(key, objPtr)
 			resCh <- result{objPtr, nil}
 		}()
 
 		select {
 		case <-ctx.Done():
-			return nil, fmt.Errorf("query db timeout, key: %s", cfg.Key)
+			return nil, fmt.Errorf("query db timeout, key: %s", key)
 		case res := <-resCh:
 			return res.data, res.err
 		}
@@ -129,7 +139,7 @@ func cacheableHandler[T any](cfg *CacheConfig[T], option *ExecOption, next Handl
 func cacheEvictInterceptor[T any](cfg *CacheConfig[T], option *ExecOption, next Handler) (any, error) {
 	// 先删缓存
 	if cfg.BeforeInvocation {
-		cfg.Manager.Delete(cfg.Key)
+		cfg.Manager.Delete(cfg.key(option))
 	}
 
 	// 再更新数据
@@ -139,7 +149,7 @@ func cacheEvictInterceptor[T any](cfg *CacheConfig[T], option *ExecOption, next
 	}
 
 	if !cfg.BeforeInvocation {
-		cfg.Manager.Delete(cfg.Key)
+		cfg.Manager.Delete(cfg.key(option))
 	}
 
 	return res, nil
@@ -0,0 +1,56 @@
+package dbgenerator
+
+import (
+	"reflect"
+
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/parser/types"
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/errors"
+)
+
+const defaultDialectName = "mysql"
+
+// 生成代码时使用的数据库方言
+type sqlDialect struct {
+	name        string
+	runtimeName string // vulcan包中方言变量的名称
+	returning   bool   // 是否通过RETURNING获取自增主键
+}
+
+var sqlDialects = map[string]*sqlDialect{
+	"mysql":    {name: "mysql", runtimeName: "MySQL"},
+	"postgres": {name: "postgres", runtimeName: "Postgres", returning: true},
+	"sqlite":   {name: "sqlite", runtimeName: "SQLite", returning: true},
+}
+
+func (g *FileGenerator) dialect() (*sqlDialect, error) {
+	name := defaultDialectName
+	if g.options != nil && g.options.Dialect != "" {
+		name = g.options.Dialect
+	}
+
+	d, ok := sqlDialects[name]
+	if !ok {
+		return nil, errors.Errorf("unsupported dialect %s, supported dialects: mysql, postgres, sqlite", name)
+	}
+
+	return d, nil
+}
+
+// 使用RETURNING获取自增主键时返回主键列名, 否则返回空字符串
+func (g *FileGenerator) returningColumn(decl *types.Declaration) string {
+	if g.sqlDialect == nil || !g.sqlDialect.returning || decl.SqlFuncDecl.SQLAnnotation.Name != types.SQLInsertFunc {
+		return ""
+	}
+
+	pkParam, _ := findPrimaryKeyParam(decl)
+	if pkParam == nil {
+		return ""
+	}
+	switch pkParam.Type.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return primaryKeyColumn(pkParam)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,107 @@
+package dbgenerator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/astutils"
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/parser/types"
+)
+
+const (
+	funcNameFillMeta  = "FillMeta"
+	fillFieldTypeName = "FillField"
+	fillInsertName    = "FillInsert"
+	fillUpdateName    = "FillUpdate"
+)
+
+// 构建插入或更新语句参数中结构体字段的自动填充语句, 在绑定参数之前执行
+//
+//	if err := vulcan.FillMeta(nil, opts, vulcan.FillInsert, []vulcan.FillField{{Column: "created_at", Value: &user.CreatedAt}}); err != nil {
+//		return err
+//	}
+//
+// 参数为切片时在循环中填充每个元素
+func (g *FileGenerator) generateFillStmts(decl *types.Declaration) []ast.Stmt {
+	fillName := fillInsertName
+	switch decl.SqlFuncDecl.SQLAnnotation.Name {
+	case types.SQLInsertFunc:
+	case types.SQLUpdateFunc:
+		fillName = fillUpdateName
+	default:
+		return nil
+	}
+
+	names := make([]string, 0, len(decl.SqlFuncDecl.InputParam))
+	for name := range decl.SqlFuncDecl.InputParam {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var stmts []ast.Stmt
+	for _, name := range names {
+		typ := &decl.SqlFuncDecl.InputParam[name].Type
+		structType := typ.GetValueType()
+		if !structType.IsStruct() {
+			continue
+		}
+		fields := types.FillFields(structType, fillName == fillUpdateName)
+		if len(fields) == 0 {
+			continue
+		}
+
+		// 切片参数使用索引访问元素, 元素为结构体或结构体指针时都可以取到字段的地址
+		for typ.IsPointer() && typ.ValueType != nil {
+			typ = typ.ValueType
+		}
+		if !typ.IsSlice() {
+			stmts = append(stmts, g.buildFillStmt(decl, fillName, ast.NewIdent(name), fields))
+			continue
+		}
+		index := g.getOptsName("i", decl.SqlFuncDecl.InputParam)
+		stmts = append(stmts, &ast.RangeStmt{
+			Key:  ast.NewIdent(index),
+			Tok:  token.DEFINE,
+			X:    ast.NewIdent(name),
+			Body: &ast.BlockStmt{List: []ast.Stmt{g.buildFillStmt(decl, fillName, &ast.IndexExpr{X: ast.NewIdent(name), Index: ast.NewIdent(index)}, fields)}},
+		})
+	}
+
+	return stmts
+}
+
+func (g *FileGenerator) buildFillStmt(decl *types.Declaration, fillName string, obj ast.Expr, fields []types.Pair[string, *types.Param]) ast.Stmt {
+	var ctx ast.Expr = ast.NewIdent(nilName)
+	if decl.SqlFuncDecl.ContextParam != "" {
+		ctx = ast.NewIdent(decl.SqlFuncDecl.ContextParam)
+	}
+
+	elts := make([]ast.Expr, 0, len(fields))
+	for _, field := range fields {
+		elts = append(elts, &ast.CompositeLit{
+			Elts: []ast.Expr{
+				astutils.BuildKeyValueBasicLitExpr("Column", fmt.Sprintf("%q", field.Key), token.STRING),
+				astutils.BuildKeyValueExpr("Value", astutils.BuildUnaryExpr("&", &ast.SelectorExpr{
+					X:   obj,
+					Sel: ast.NewIdent(field.Val.Name),
+				})),
+			},
+		})
+	}
+	call := astutils.BuildCallExpr(astutils.BuildSelectorExpr([]string{corePackageName, funcNameFillMeta}), []ast.Expr{
+		ctx,
+		ast.NewIdent(g.optsName),
+		astutils.BuildSelectorExpr([]string{corePackageName, fillName}),
+		&ast.CompositeLit{
+			Type: &ast.ArrayType{Elt: astutils.BuildSelectorExpr([]string{corePackageName, fillFieldTypeName})},
+			Elts: elts,
+		},
+	}, false)
+
+	ifStmt := g.generateReturnErrAst(decl.SqlFuncDecl.FuncReturnResultParam, "", decl.SqlFuncDecl.SQLAnnotation.Name)
+	ifStmt.Init = astutils.BuildDefineStmtByExpr([]ast.Expr{ast.NewIdent(errName)}, []ast.Expr{call})
+
+	return ifStmt
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/mangohow/gowlb/tools/strutil"
 
 	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/parser/types"
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/command"
 	"github.com/mangohow/vulcan/cmd/vulcan/internal/errors"
 	"github.com/mangohow/vulcan/cmd/vulcan/internal/log"
 	"github.com/mangohow/vulcan/cmd/vulcan/internal/utils"
@@ -42,6 +43,13 @@ const (
 	execOptionFieldArgsName      = "Args"
 	execOptionFieldExecerName    = "Execer"
 	execOptionFieldExtensionName = "Extension"
+	execOptionFieldDialectName   = "Dialect"
+	execOptionFieldStaticName    = "Static"
+	execOptionFieldMetaName      = "Meta"
+	execOptionFieldCtxName       = "Ctx"
+	execOptionFieldLockName      = "OptimisticLock"
+
+	execOptionApplyName = "Apply"
 
 	invokeName            = "Invoke"
 	invokePreHandlerName  = "InvokePreHandler"
@@ -55,10 +63,13 @@ const (
 	dbRowsCloseName = "Close"
 	dbRowsNextName  = "Next"
 
-	sqlTypeInsertName = "SQLTypeInsert"
-	sqlTypeUpdateName = "SQLTypeUpdate"
-	sqlTypeDeleteName = "SQLTypeDelete"
-	sqlTypeSelectName = "SQLTypeSelect"
+	// 使用RETURNING获取自增主键的插入语句
+	dbExecReturningOptName = "ExecReturning"
+
+	sqlTypeInsertName = "SqlTypeInsert"
+	sqlTypeUpdateName = "SqlTypeUpdate"
+	sqlTypeDeleteName = "SqlTypeDelete"
+	sqlTypeSelectName = "SqlTypeSelect"
 
 	nilName = "nil"
 	errName = "err"
@@ -71,6 +82,7 @@ const (
 	sqlPackageName     = "sql"
 	sqlPackagePathName = "database/sql"
 	sqlDBName          = "DB"
+	execerName         = "Execer"
 
 	sqlBuilderFuncAppendStmt                   = "AppendStmt"
 	sqlBuilderFuncAppendWhereStmtConditional   = "AppendWhereStmtConditional"
@@ -90,17 +102,36 @@ const (
 )
 
 type FileGenerator struct {
-	srcFile  *types.File
-	optsName string
+	srcFile    *types.File
+	options    *command.CommandOptions
+	optsName   string
+	sqlDialect *sqlDialect
+	argParams  map[string]*types.Param // 正在生成的动态sql中参数可以引用的变量, 用于判断敏感参数
+	// 文件中引用的模型的逻辑删除列, key为小写的表名
+	logicDeleteColumns map[string]string
+	// 文件中引用的模型在TableProperty中指定的超时时间, key为小写的表名
+	tableTimeouts map[string]time.Duration
 }
 
-func NewFileGenerator(file *types.File) *FileGenerator {
+func NewFileGenerator(file *types.File, options *command.CommandOptions) *FileGenerator {
 	return &FileGenerator{
 		srcFile: file,
+		options: options,
 	}
 }
 
 func (g *FileGenerator) Execute(filename string) error {
+	dialect, err := g.dialect()
+	if err != nil {
+		return err
+	}
+	g.sqlDialect = dialect
+	g.logicDeleteColumns = logicDeleteColumns(g.srcFile.Declarations)
+	g.tableTimeouts, err = tableTimeouts(g.srcFile.Declarations)
+	if err != nil {
+		return err
+	}
+
 	for _, d := range g.srcFile.Declarations {
 		if d.SqlFuncDecl == nil {
 			continue
@@ -116,7 +147,7 @@ func (g *FileGenerator) Execute(filename string) error {
 	}
 
 	// 格式化代码
-	err := exec.Command("go", "fmt", filename).Run()
+	err = exec.Command("go", "fmt", filename).Run()
 	if err != nil {
 		log.Errorf("run go fmt failed, %v", err)
 	}
@@ -130,6 +161,19 @@ func (g *FileGenerator) generateAst(decl *types.Declaration) error {
 	if !ok {
 		return errors.Errorf("convert to *ast.FuncDecl type failed")
 	}
+
+	// 开启了context选项并且函数没有声明context参数时, 添加ctx参数作为第一个参数
+	if decl.SqlFuncDecl.ContextParam == "" && g.options != nil && g.options.Context {
+		ctxName := g.getOptsName("ctx", decl.SqlFuncDecl.InputParam)
+		ctxField := &ast.Field{
+			Names: []*ast.Ident{ast.NewIdent(ctxName)},
+			Type:  astutils.BuildSelectorExpr([]string{types.ContextPackagePath, types.ContextTypeName}),
+		}
+		fnDecl.Type.Params.List = append([]*ast.Field{ctxField}, fnDecl.Type.Params.List...)
+		decl.SqlFuncDecl.ContextParam = ctxName
+		g.addImport(types.ContextPackagePath)
+	}
+
 	optsMame := g.getOptsName("opts", decl.SqlFuncDecl.InputParam)
 	g.optsName = optsMame
 	ellipsis := astutils.BuildEllipsisField(optsMame, "vulcan.Option")
@@ -155,6 +199,18 @@ func (g *FileGenerator) generateAst(decl *types.Declaration) error {
 }
 
 func (g *FileGenerator) generateFuncBodyAst(decl *types.Declaration) (*ast.BlockStmt, error) {
+	body, err := g.generateSqlFuncBodyAst(decl)
+	if err != nil {
+		return nil, err
+	}
+
+	// 自动填充字段需要在绑定参数之前执行
+	body.List = append(g.generateFillStmts(decl), body.List...)
+
+	return body, nil
+}
+
+func (g *FileGenerator) generateSqlFuncBodyAst(decl *types.Declaration) (*ast.BlockStmt, error) {
 	sql := decl.SqlFuncDecl.Sql[0]
 	switch sql.(type) {
 	case types.RawSQL:
@@ -230,50 +286,7 @@ func (g *FileGenerator) generateReturnErrAst(param *types.Param, sqlExecuteResul
 //
 // user.Id = lastInsertedId
 func (g *FileGenerator) generatePrimaryKeyAssign(options *sqlGenOptions, decl *types.Declaration) []ast.Stmt {
-	var (
-		findPrimaryKey func(param *types.Param) *types.Param
-		names          []string
-	)
-	findPrimaryKey = func(param *types.Param) *types.Param {
-		if param == nil {
-			return nil
-		}
-		if param.Type.IsSlice() {
-			return nil
-		}
-		if param.Type.IsPointer() {
-			return findPrimaryKey(&types.Param{Name: param.Name, Type: *param.Type.ValueType})
-		}
-
-		if param.Type.IsBasicType() {
-			if tag := param.Type.Tag.Get("db"); tag != "" && strings.Contains(tag, entityFiledPrimaryKeyName) {
-				return param
-			}
-		}
-
-		names = append(names, param.Name)
-		for _, field := range param.Type.Fields {
-			pkType := findPrimaryKey(field)
-			if pkType != nil {
-				names = append(names, field.Name)
-				return pkType
-			}
-		}
-
-		return nil
-	}
-
-	var (
-		pkParam *types.Param
-	)
-	inputParams := utils.Values(decl.SqlFuncDecl.InputParam)
-	for i := 0; i < len(inputParams); i++ {
-		pkParam = findPrimaryKey(inputParams[i])
-		if pkParam != nil {
-			break
-		}
-	}
-
+	pkParam, names := findPrimaryKeyParam(decl)
 	if pkParam == nil {
 		return nil
 	}
@@ -319,6 +332,59 @@ func (g *FileGenerator) generateRowAffectedAssignAndReturnStmt(options *sqlGenOp
 	return []ast.Stmt{assignStmt, errReturnStmt, astutils.BuildEmptyStmt(), returnStmt}
 }
 
+// 在入参中查找自增主键字段, 返回主键字段以及访问该字段的路径
+func findPrimaryKeyParam(decl *types.Declaration) (*types.Param, []string) {
+	var (
+		findPrimaryKey func(param *types.Param) *types.Param
+		names          []string
+	)
+	findPrimaryKey = func(param *types.Param) *types.Param {
+		if param == nil {
+			return nil
+		}
+		if param.Type.IsSlice() {
+			return nil
+		}
+		if param.Type.IsPointer() {
+			return findPrimaryKey(&types.Param{Name: param.Name, Type: *param.Type.ValueType})
+		}
+
+		if param.Type.IsBasicType() {
+			if tag := param.Type.Tag.Get("db"); tag != "" && strings.Contains(tag, entityFiledPrimaryKeyName) {
+				return param
+			}
+		}
+
+		names = append(names, param.Name)
+		for _, field := range param.Type.Fields {
+			pkType := findPrimaryKey(field)
+			if pkType != nil {
+				names = append(names, field.Name)
+				return pkType
+			}
+		}
+		// 结构体中没有主键字段, 如嵌入的TableProperty
+		names = names[:len(names)-1]
+
+		return nil
+	}
+
+	inputParams := utils.Values(decl.SqlFuncDecl.InputParam)
+	for i := 0; i < len(inputParams); i++ {
+		names = names[:0]
+		if pkParam := findPrimaryKey(inputParams[i]); pkParam != nil {
+			return pkParam, names
+		}
+	}
+
+	return nil, nil
+}
+
+// 获取主键对应的列名, db tag的第一项为列名
+func primaryKeyColumn(pkParam *types.Param) string {
+	return strings.TrimSpace(strings.Split(pkParam.Type.Tag.Get("db"), ",")[0])
+}
+
 type sqlGenOptions struct {
 	*sqlutils.SqlParseResult
 	execOptionName          string
@@ -334,6 +400,7 @@ type sqlGenOptions struct {
 	selectRowsName          string
 	selectObjName           string
 	builderName             string
+	contextName             string
 }
 
 // 对sql代码生成进行预处理
@@ -342,6 +409,9 @@ func preprocessingSqlGen(decl *types.Declaration, sql string) (*sqlGenOptions, e
 	if decl.SqlFuncDecl.Receiver != nil {
 		usedNames.Add(decl.SqlFuncDecl.Receiver.Name)
 	}
+	if decl.SqlFuncDecl.ContextParam != "" {
+		usedNames.Add(decl.SqlFuncDecl.ContextParam)
+	}
 	for _, v := range decl.SqlFuncDecl.OutputParam {
 		if v.Name != "" {
 			usedNames.Add(v.Name)
@@ -368,6 +438,7 @@ func preprocessingSqlGen(decl *types.Declaration, sql string) (*sqlGenOptions, e
 			selectRowsName:         getAvailableName("rows", usedNames),
 			selectObjName:          getAvailableName("obj", usedNames),
 			builderName:            getAvailableName("builder", usedNames),
+			contextName:            decl.SqlFuncDecl.ContextParam,
 		}
 	)
 
@@ -387,7 +458,6 @@ func preprocessingSqlGen(decl *types.Declaration, sql string) (*sqlGenOptions, e
 		if decl.SqlFuncDecl.FuncReturnResultParam.Type.IsSlice() {
 			sqlOperationName = dbSelectOptName
 		}
-		sqlExecuteResultName = sqlExecuteResultName[1:]
 		options.newResultOptionArgsName = append(options.newResultOptionArgsName, corePackageName+"."+sqlTypeSelectName, options.sqlOperationResultName, errName, nilName)
 	default:
 		return nil, errors.Errorf("annotation error")
@@ -424,6 +494,9 @@ func getReceiverDbFieldName(param *types.Param) string {
 		if typeSpec.Name == sqlDBName && typeSpec.Package.PackageName == sqlPackageName && typeSpec.Package.PackagePath == sqlPackagePathName {
 			return field.Name
 		}
+		if typeSpec.Name == execerName && typeSpec.Package != nil && typeSpec.Package.PackagePath == corePackagePath {
+			return field.Name
+		}
 	}
 
 	return ""
@@ -431,6 +504,7 @@ func getReceiverDbFieldName(param *types.Param) string {
 
 func (g *FileGenerator) generateDynamicSqlFuncBodyAst(decl *types.Declaration, options *sqlGenOptions) (*ast.BlockStmt, error) {
 	blockStmt := &ast.BlockStmt{}
+	g.argParams = decl.SqlFuncDecl.InputParam
 
 	var (
 		whereInitial, setInitial = g.statisticsInitialCapacity(decl.SqlFuncDecl.Sql)
@@ -517,13 +591,27 @@ func (g *FileGenerator) generateCommonCode(decl *types.Declaration, options *sql
 		Type: astutils.BuildIdentOrSelectorExpr(corePackageName + "." + "ExecOption"),
 	}
 
+	// 支持RETURNING的方言通过RETURNING获取自增主键
+	returningColumn := g.returningColumn(decl)
+	returningClause := ""
+	if returningColumn != "" {
+		returningClause = " RETURNING " + returningColumn
+	}
 	if isDynamic {
+		var sqlExpr ast.Expr = astutils.BuildSimpleCall(ast.NewIdent(options.builderName), ast.NewIdent(sqlBuilderFuncString))
+		if returningClause != "" {
+			sqlExpr = &ast.BinaryExpr{
+				X:  sqlExpr,
+				Op: token.ADD,
+				Y:  astutils.BuildBasicLit(token.STRING, fmt.Sprintf("%q", returningClause)),
+			}
+		}
 		composite.Elts = []ast.Expr{
-			astutils.BuildKeyValueExpr(execOptionFieldSqlStmtName, astutils.BuildSimpleCall(ast.NewIdent(options.builderName), ast.NewIdent(sqlBuilderFuncString))),
+			astutils.BuildKeyValueExpr(execOptionFieldSqlStmtName, sqlExpr),
 		}
 	} else {
 		composite.Elts = []ast.Expr{
-			astutils.BuildKeyValueBasicLitExpr(execOptionFieldSqlStmtName, fmt.Sprintf("%q", options.SQL), token.STRING),
+			astutils.BuildKeyValueBasicLitExpr(execOptionFieldSqlStmtName, fmt.Sprintf("%q", options.SQL+returningClause), token.STRING),
 		}
 	}
 
@@ -544,6 +632,21 @@ func (g *FileGenerator) generateCommonCode(decl *types.Declaration, options *sql
 	}
 	composite.Elts = append(composite.Elts, astutils.BuildKeyValueExpr(execOptionFieldExecerName, astutils.BuildIdentOrSelectorExpr(options.receiverName+"."+options.receiverDbFieldName)))
 
+	// 如果函数有context参数, 则传入ExecOption
+	if options.contextName != "" {
+		composite.Elts = append(composite.Elts, astutils.BuildKeyValueExpr(execOptionFieldCtxName, ast.NewIdent(options.contextName)))
+	}
+
+	// 静态sql可以使用预编译语句缓存
+	if !isDynamic {
+		composite.Elts = append(composite.Elts, astutils.BuildKeyValueExpr(execOptionFieldStaticName, ast.NewIdent("true")))
+	}
+
+	// 非默认方言需要指定方言, 执行时转换占位符
+	if g.sqlDialect != nil && g.sqlDialect.name != defaultDialectName {
+		composite.Elts = append(composite.Elts, astutils.BuildKeyValueExpr(execOptionFieldDialectName, astutils.BuildSelectorExpr([]string{corePackageName, g.sqlDialect.runtimeName})))
+	}
+
 	// 如果有扩展字段则需要传入ExecOption
 	for _, param := range decl.SqlFuncDecl.InputParam {
 		if types.IsRegisteredExtension(param) {
@@ -551,6 +654,22 @@ func (g *FileGenerator) generateCommonCode(decl *types.Declaration, options *sql
 		}
 	}
 
+	// sql元信息, 执行失败时用于构建错误信息
+	staticSql := ""
+	if !isDynamic {
+		staticSql = options.SQL
+	}
+	var sensitive []int
+	if !isDynamic {
+		sensitive = sensitiveArgIndexes(decl.SqlFuncDecl.InputParam, options.ParamsName)
+	}
+
+	// 更新带有乐观锁版本字段的结构体时设置乐观锁
+	if lock := buildOptimisticLockExpr(decl, staticSql); lock != nil {
+		composite.Elts = append(composite.Elts, astutils.BuildKeyValueExpr(execOptionFieldLockName, lock))
+	}
+	composite.Elts = append(composite.Elts, astutils.BuildKeyValueExpr(execOptionFieldMetaName, g.buildStatementMetaExpr(decl, staticSql, isDynamic, sensitive)))
+
 	optionAssign := &ast.AssignStmt{
 		Lhs: []ast.Expr{ast.NewIdent(options.execOptionName)},
 		Rhs: []ast.Expr{astutils.BuildUnaryExpr("&", composite)},
@@ -558,6 +677,11 @@ func (g *FileGenerator) generateCommonCode(decl *types.Declaration, options *sql
 	}
 	resList = append(resList, optionAssign)
 
+	// 执行传入的Option
+	// option.Apply(opts...)
+	applyCallExpr := astutils.BuildCallExpr(astutils.BuildSelectorExpr([]string{options.execOptionName, execOptionApplyName}), []ast.Expr{ast.NewIdent(g.optsName)}, true)
+	resList = append(resList, &ast.ExprStmt{X: applyCallExpr})
+
 	// 构建vulcan.Invoke调用
 	// 先构建回调函数
 	callbackFunc := &ast.FuncLit{
@@ -569,21 +693,9 @@ func (g *FileGenerator) generateCommonCode(decl *types.Declaration, options *sql
 	// 设置回调函数返回值
 	switch decl.SqlFuncDecl.SQLAnnotation.Name {
 	case types.SQLSelectFunc:
-		field := &ast.Field{}
-		returnType := &decl.SqlFuncDecl.FuncReturnResultParam.Type
-		typeName := returnType.Name
-		if returnType.Package != nil && returnType.Package.PackageName != "" {
-			typeName = returnType.Package.PackageName + "." + returnType.Name
-		}
-		expr := astutils.BuildIdentOrSelectorExpr(typeName)
-		if returnType.IsPointer() {
-			field.Type = &ast.StarExpr{
-				X: expr,
-			}
-		} else {
-			field.Type = expr
-		}
-		callbackFunc.Type.Results.List = append(callbackFunc.Type.Results.List, field)
+		callbackFunc.Type.Results.List = append(callbackFunc.Type.Results.List, &ast.Field{
+			Type: buildTypeExpr(&decl.SqlFuncDecl.FuncReturnResultParam.Type),
+		})
 	case types.SQLInsertFunc, types.SQLDeleteFunc, types.SQLUpdateFunc:
 		callbackFunc.Type.Results.List = append(callbackFunc.Type.Results.List, &ast.Field{
 			Type: astutils.BuildSelectorExpr([]string{"sql", "Result"}),
@@ -620,19 +732,31 @@ func (g *FileGenerator) generateCommonCode(decl *types.Declaration, options *sql
 	} else {
 		// 处理sql执行语句
 		// return option.Exec(option.SqlStmt, option.Args...)
-		returnStmt := astutils.BuildReturnStmtByExpr(astutils.BuildSimpleCall(ast.NewIdent(options.execOptionName), ast.NewIdent(dbExecOptName)))
+		execName := dbExecOptName
+		if returningColumn != "" {
+			execName = dbExecReturningOptName
+		}
+		returnStmt := astutils.BuildReturnStmtByExpr(astutils.BuildSimpleCall(ast.NewIdent(options.execOptionName), ast.NewIdent(execName)))
 		callbackFunc.Body.List = append(callbackFunc.Body.List, returnStmt)
 	}
+	// 如果是插入语句, 可能需要给自增Id赋值
+	var pkAssignStmts []ast.Stmt
+	if decl.SqlFuncDecl.SQLAnnotation.Name == types.SQLInsertFunc {
+		pkAssignStmts = g.generatePrimaryKeyAssign(options, decl)
+	}
+
 	// 构建vulcan.Invoke函数调用
 	var leftExpr []ast.Expr
-	if decl.SqlFuncDecl.SQLAnnotation.Name != types.SQLSelectFunc && decl.SqlFuncDecl.FuncReturnResultParam == nil {
+	if decl.SqlFuncDecl.SQLAnnotation.Name != types.SQLSelectFunc && decl.SqlFuncDecl.FuncReturnResultParam == nil && len(pkAssignStmts) == 0 {
 		leftExpr = astutils.BuildIdentList("_", options.sqlExecuteResultName[1])
 	} else {
 		leftExpr = astutils.BuildIdentList(options.sqlExecuteResultName...)
 	}
 	invokeCallExpr := astutils.BuildDefineStmtByExpr(leftExpr, []ast.Expr{
-		ast.NewIdent(options.execOptionName),
-		callbackFunc,
+		astutils.BuildCallExpr(astutils.BuildSelectorExpr([]string{corePackageName, invokeName}), []ast.Expr{
+			ast.NewIdent(options.execOptionName),
+			callbackFunc,
+		}, false),
 	})
 	resList = append(resList, invokeCallExpr)
 
@@ -648,13 +772,10 @@ func (g *FileGenerator) generateCommonCode(decl *types.Declaration, options *sql
 	//		return 0, err
 	//	}
 	//	user.Id = id
-	if decl.SqlFuncDecl.SQLAnnotation.Name == types.SQLInsertFunc {
-		stmts := g.generatePrimaryKeyAssign(options, decl)
-		if len(stmts) > 0 {
-			// 添加空行
-			resList = append(resList, astutils.BuildEmptyStmt())
-			resList = append(resList, stmts...)
-		}
+	if len(pkAssignStmts) > 0 {
+		// 添加空行
+		resList = append(resList, astutils.BuildEmptyStmt())
+		resList = append(resList, pkAssignStmts...)
 	}
 
 	if decl.SqlFuncDecl.FuncReturnResultParam != nil {
@@ -708,7 +829,7 @@ func (g *FileGenerator) generateIfStmtAstHelper(stmts []*types.IfStmt, builderVa
 		}
 
 		inner.Args = append(inner.Args, astutils.BuildBasicLit(token.STRING, fmt.Sprintf("%q", stmt.Sql)))
-		inner.Args = append(inner.Args, astutils.BuildIdentOrSelectorExprList(stmt.Args)...)
+		inner.Args = append(inner.Args, g.buildArgExprList(stmt.Args)...)
 		x = inner
 	}
 	astStmts = []ast.Stmt{&ast.ExprStmt{X: astutils.BuildSimpleCall(x, ast.NewIdent(endFuncName))}}
@@ -725,7 +846,7 @@ func (g *FileGenerator) generateChooseStmtAstHelper(whens []*types.WhenStmt, oth
 			astutils.BuildBasicLit(token.STRING, fmt.Sprintf("%q", when.Sql)),
 		}
 		condSqls = append(condSqls, astutils.BuildCallExpr(astutils.BuildIdentOrSelectorExpr(corePackageName+"."+funcNameNewConditionSql), callArgs, false))
-		callArgs = append(callArgs, astutils.BuildIdentOrSelectorExprList(when.Args)...)
+		callArgs = append(callArgs, g.buildArgExprList(when.Args)...)
 	}
 	arg1 := astutils.BuildCallExpr(astutils.BuildIdentOrSelectorExpr(corePackageName+"."+funcNameMakeSlice), condSqls, false)
 	arg2 := astutils.BuildBasicLit(token.STRING, fmt.Sprintf("%q", otherwise))
@@ -733,7 +854,7 @@ func (g *FileGenerator) generateChooseStmtAstHelper(whens []*types.WhenStmt, oth
 	if len(otherwiseArgs) == 0 {
 		args = append(args, ast.NewIdent("nil"))
 	} else {
-		args = append(args, astutils.BuildIdentOrSelectorExprList(otherwiseArgs)...)
+		args = append(args, g.buildArgExprList(otherwiseArgs)...)
 	}
 
 	astStmts = []ast.Stmt{
@@ -762,7 +883,7 @@ func (g *FileGenerator) generateIfStmtAst(stmt *types.IfStmt, builderVarName str
 		stmt.CondExpr,
 		astutils.BuildBasicLit(token.STRING, fmt.Sprintf("%q", stmt.Sql)),
 	}
-	funcArgs = append(funcArgs, astutils.BuildIdentOrSelectorExprList(stmt.Args)...)
+	funcArgs = append(funcArgs, g.buildArgExprList(stmt.Args)...)
 	astStmts = append(astStmts, &ast.ExprStmt{
 		X: astutils.BuildCallExpr(astutils.BuildIdentOrSelectorExpr(builderVarName+"."+sqlBuilderFuncAppendStmtConditional), funcArgs, false),
 	})
@@ -787,13 +908,20 @@ func (g *FileGenerator) generateForeachStmtAst(stmt *types.ForeachStmt, builderV
 	} else {
 		typeExpr = astutils.BuildIdentOrSelectorExpr(stmt.ItemType)
 	}
+	// 循环中的参数引用集合元素
+	params := g.argParams
+	if collection, ok := params[stmt.CollectionName]; ok && collection.Type.IsSlice() {
+		g.argParams = map[string]*types.Param{stmt.ItemName: {Name: stmt.ItemName, Type: *collection.Type.ValueType}}
+	}
+	itemArgs := g.buildArgExprList(stmt.Args)
+	g.argParams = params
 	returnStmt := &ast.ReturnStmt{
 		Results: []ast.Expr{
 			&ast.CompositeLit{
 				Type: &ast.ArrayType{
 					Elt: ast.NewIdent("any"),
 				},
-				Elts: astutils.BuildIdentOrSelectorExprList(stmt.Args),
+				Elts: itemArgs,
 			},
 		},
 	}
@@ -832,7 +960,7 @@ func (g *FileGenerator) generateForeachStmtAst(stmt *types.ForeachStmt, builderV
 
 func (g *FileGenerator) generateSimpleStmtAst(stmt *types.SimpleStmt, builderVarName string) (astStmts []ast.Stmt, sqlLen int) {
 	funcArgs := []ast.Expr{astutils.BuildBasicLit(token.STRING, fmt.Sprintf("%q", stmt.Sql))}
-	funcArgs = append(funcArgs, astutils.BuildIdentOrSelectorExprList(stmt.Args)...)
+	funcArgs = append(funcArgs, g.buildArgExprList(stmt.Args)...)
 	astStmts = append(astStmts, &ast.ExprStmt{
 		X: astutils.BuildCallExpr(astutils.BuildIdentOrSelectorExpr(builderVarName+"."+sqlBuilderFuncAppendStmt), funcArgs, false),
 	})
@@ -889,6 +1017,17 @@ func (g *FileGenerator) getOptsName(optsName string, inputParm map[string]*types
 	return optsName
 }
 
+func (g *FileGenerator) addImport(pkg string) {
+	path := fmt.Sprintf("%q", pkg)
+	for _, spec := range g.srcFile.PkgInfo.AstImports {
+		if spec.Path.Value == path {
+			return
+		}
+	}
+
+	g.srcFile.PkgInfo.AstImports = append(g.srcFile.PkgInfo.AstImports, astutils.BuildImportSpec(pkg))
+}
+
 func (g *FileGenerator) generateCode(filename string) error {
 	astFile := &ast.File{
 		Name:     g.srcFile.AstFile.Name,
@@ -1007,6 +1146,22 @@ func (g *FileGenerator) generateCode(filename string) error {
 		", Execer:", ",\n\t\tExecer:",
 		", Args:", ",\n\t\tArgs:",
 		", Extension:", ",\n\t\tExtension:",
+		", Ctx:", ",\n\t\tCtx:",
+		", Static:", ",\n\t\tStatic:",
+		", Dialect:", ",\n\t\tDialect:",
+		", OptimisticLock:", ",\n\t\tOptimisticLock:",
+		", Meta:", ",\n\t\tMeta:",
+		// Meta总是最后一个字段, 元信息的每个字段单独一行
+		"StatementMeta{", "StatementMeta{\n\t\t\t",
+		", Method:", ",\n\t\t\tMethod:",
+		", Kind:", ",\n\t\t\tKind:",
+		", Table:", ",\n\t\t\tTable:",
+		", LogicDelete:", ",\n\t\t\tLogicDelete:",
+		", Source:", ",\n\t\t\tSource:",
+		", Dynamic:", ",\n\t\t\tDynamic:",
+		", Sensitive:", ",\n\t\t\tSensitive:",
+		", Timeout:", ",\n\t\t\tTimeout:",
+		"}}\n", ",\n\t\t},\n\t}\n",
 		endKey, ",\n\t}\n",
 	}...)
 	for {
@@ -1049,6 +1204,10 @@ func (g *FileGenerator) formatFuncSource(source string) string {
 		").AppendWhereStmtConditional", ").\n\t\tAppendWhereStmtConditional",
 		").AppendSetStmtConditional", ").\n\t\tAppendSetStmtConditional",
 		"vulcan.NewConditionSql", "\n\t\tvulcan.NewConditionSql",
+		// 自动填充的每个字段单独一行
+		"[]vulcan.FillField{{", "[]vulcan.FillField{\n\t\t{",
+		"}, {Column:", "},\n\t\t{Column:",
+		"}}); err != nil", "},\n\t}); err != nil",
 	)
 	return replacer.Replace(source)
 }
@@ -1107,12 +1266,9 @@ func (g *FileGenerator) generateDBSelectStmt(decl *types.Declaration, options *s
 }
 
 func (g *FileGenerator) generateDBGetStmt(decl *types.Declaration, options *sqlGenOptions) []ast.Stmt {
-	// 构建option.Get(arg1, arg2).Scan(arg3, arg4, ...)语句
+	// 构建option.Get().Scan(arg1, arg2, ...)语句
 	// 先构建option.Get()
-	getExpr := astutils.BuildCallExpr(astutils.BuildSelectorExpr([]string{options.execOptionName, dbGetOptName}), []ast.Expr{
-		astutils.BuildSelectorExpr([]string{options.execOptionName, execOptionFieldSqlStmtName}),
-		astutils.BuildSelectorExpr([]string{options.execOptionName, execOptionFieldArgsName}),
-	}, true)
+	getExpr := astutils.BuildCallExpr(astutils.BuildSelectorExpr([]string{options.execOptionName, dbGetOptName}), nil, false)
 
 	var scanArgsExpr []ast.Expr
 	if decl.SqlFuncDecl.FuncReturnResultParam.Type.GetValueType().IsBasicType() {
@@ -1144,3 +1300,24 @@ func roundUp(n int) int {
 	}
 	return 1 << bits.Len(uint(n-1))
 }
+
+// 根据类型信息构建类型表达式, 如[]*model.User
+func buildTypeExpr(typeSpec *types.TypeSpec) ast.Expr {
+	switch {
+	case typeSpec.IsSlice() && typeSpec.ValueType != nil:
+		return &ast.ArrayType{Elt: buildTypeExpr(typeSpec.ValueType)}
+	case typeSpec.IsPointer() && typeSpec.ValueType != nil:
+		return &ast.StarExpr{X: buildTypeExpr(typeSpec.ValueType)}
+	}
+
+	typeName := typeSpec.Name
+	if typeSpec.Package != nil && typeSpec.Package.PackageName != "" {
+		typeName = typeSpec.Package.PackageName + "." + typeSpec.Name
+	}
+	expr := astutils.BuildIdentOrSelectorExpr(typeName)
+	if typeSpec.IsPointer() {
+		return &ast.StarExpr{X: expr}
+	}
+
+	return expr
+}
@@ -0,0 +1,70 @@
+package dbgenerator
+
+import (
+	"flag"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/parser"
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/parser/dbparser"
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/command"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestFileGeneratorGolden(t *testing.T) {
+	tests := []struct {
+		file    string
+		options *command.CommandOptions
+	}{
+		{file: "usermapper.go"},
+		{file: "ordermapper.go", options: &command.CommandOptions{Context: true}},
+		{file: "pgmapper.go", options: &command.CommandOptions{Dialect: "postgres"}},
+		{file: "accountmapper.go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			src, err := filepath.Abs(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fst := token.NewFileSet()
+			parsed, err := dbparser.NewFileParser(fst, parser.NewDependencyManager(fst)).Parse(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out := filepath.Join(t.TempDir(), tt.file)
+			if err := NewFileGenerator(parsed, tt.options).Execute(out); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 生成后的go fmt依赖包导入, 在测试中统一格式化
+			if got, err = format.Source(got); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", strings.TrimSuffix(tt.file, ".go")+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(expected) {
+				t.Fatalf("generated code mismatch %s\n%s", golden, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package dbgenerator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mangohow/gowlb/tools/stream"
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/astutils"
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/parser/types"
+)
+
+const (
+	statementMetaTypeName = "StatementMeta"
+	funcNameSensitive     = "Sensitive"
+	optimisticLockName    = "OptimisticLock"
+	timePackagePath       = "time"
+	timePackageName       = "time"
+)
+
+// 匹配sql操作的表名, 如FROM t_user、INTO t_user、UPDATE t_user
+var sqlTableRegex = regexp.MustCompile("(?i)\\b(?:FROM|INTO|UPDATE)\\s+([`\"\\w.]+)")
+
+// 构建sql语句的元信息
+// &vulcan.StatementMeta{Mapper: "UserMapper", Method: "Add", Kind: vulcan.SqlTypeInsert, Table: "t_user", Source: "usermapper.go:10"}
+func (g *FileGenerator) buildStatementMetaExpr(decl *types.Declaration, sql string, isDynamic bool, sensitive []int) ast.Expr {
+	elts := make([]ast.Expr, 0, 8)
+	if mapper := receiverTypeName(decl.SqlFuncDecl.Receiver); mapper != "" {
+		elts = append(elts, astutils.BuildKeyValueBasicLitExpr("Mapper", fmt.Sprintf("%q", mapper), token.STRING))
+	}
+	elts = append(elts, astutils.BuildKeyValueBasicLitExpr("Method", fmt.Sprintf("%q", decl.SqlFuncDecl.FuncName), token.STRING))
+	if kind := sqlTypeName(decl.SqlFuncDecl.SQLAnnotation.Name); kind != "" {
+		elts = append(elts, astutils.BuildKeyValueExpr("Kind", astutils.BuildSelectorExpr([]string{corePackageName, kind})))
+	}
+	if table := sqlTableName(decl, sql); table != "" {
+		elts = append(elts, astutils.BuildKeyValueBasicLitExpr("Table", fmt.Sprintf("%q", table), token.STRING))
+		if column := g.logicDeleteColumns[strings.ToLower(table)]; column != "" && decl.SqlFuncDecl.SQLAnnotation.Name == types.SQLSelectFunc {
+			elts = append(elts, astutils.BuildKeyValueBasicLitExpr("LogicDelete", fmt.Sprintf("%q", column), token.STRING))
+		}
+	}
+	if decl.SqlFuncDecl.Source != "" {
+		elts = append(elts, astutils.BuildKeyValueBasicLitExpr("Source", fmt.Sprintf("%q", decl.SqlFuncDecl.Source), token.STRING))
+	}
+	if isDynamic {
+		elts = append(elts, astutils.BuildKeyValueExpr("Dynamic", ast.NewIdent("true")))
+	}
+	if len(sensitive) > 0 {
+		elts = append(elts, astutils.BuildKeyValueExpr("Sensitive", &ast.CompositeLit{
+			Type: &ast.ArrayType{Elt: ast.NewIdent("int")},
+			Elts: stream.Map(sensitive, func(i int) ast.Expr {
+				return astutils.BuildBasicLit(token.INT, strconv.Itoa(i))
+			}),
+		}))
+	}
+	// Timeout注解优先, 没有时使用模型TableProperty中指定的超时时间
+	timeout := decl.SqlFuncDecl.Timeout
+	if timeout == 0 {
+		timeout = g.tableTimeouts[strings.ToLower(sqlTableName(decl, sql))]
+	}
+	if timeout > 0 {
+		g.addImport(timePackagePath)
+		elts = append(elts, astutils.BuildKeyValueExpr("Timeout", buildDurationExpr(timeout)))
+	}
+
+	return astutils.BuildUnaryExpr("&", &ast.CompositeLit{
+		Type: astutils.BuildSelectorExpr([]string{corePackageName, statementMetaTypeName}),
+		Elts: elts,
+	})
+}
+
+// 获取注解对应的vulcan.SqlType常量名称
+func sqlTypeName(annotation string) string {
+	switch annotation {
+	case types.SQLInsertFunc:
+		return sqlTypeInsertName
+	case types.SQLDeleteFunc:
+		return sqlTypeDeleteName
+	case types.SQLUpdateFunc:
+		return sqlTypeUpdateName
+	case types.SQLSelectFunc:
+		return sqlTypeSelectName
+	default:
+		return ""
+	}
+}
+
+func receiverTypeName(receiver *types.Param) string {
+	if receiver == nil {
+		return ""
+	}
+
+	typ := &receiver.Type
+	for typ.IsPointer() && typ.ValueType != nil {
+		typ = typ.ValueType
+	}
+
+	return typ.Name
+}
+
+// 收集文件中sql函数的参数以及返回值引用的模型的逻辑删除列, key为小写的表名
+func logicDeleteColumns(decls []types.Declaration) map[string]string {
+	res := make(map[string]string)
+	collect := func(params map[string]*types.Param) {
+		for _, param := range params {
+			table, column := types.LogicDeleteColumn(param.Type.GetValueType())
+			if table != "" {
+				res[strings.ToLower(table)] = column
+			}
+		}
+	}
+	for _, decl := range decls {
+		if decl.SqlFuncDecl == nil {
+			continue
+		}
+		collect(decl.SqlFuncDecl.InputParam)
+		collect(decl.SqlFuncDecl.OutputParam)
+	}
+
+	return res
+}
+
+// 收集文件中sql函数的参数以及返回值引用的模型在TableProperty中指定的超时时间, key为小写的表名
+func tableTimeouts(decls []types.Declaration) (map[string]time.Duration, error) {
+	res := make(map[string]time.Duration)
+	collect := func(params map[string]*types.Param) error {
+		for _, param := range params {
+			table, timeout, err := types.TableTimeout(param.Type.GetValueType())
+			if err != nil {
+				return err
+			}
+			if table != "" {
+				res[strings.ToLower(table)] = timeout
+			}
+		}
+		return nil
+	}
+	for _, decl := range decls {
+		if decl.SqlFuncDecl == nil {
+			continue
+		}
+		if err := collect(decl.SqlFuncDecl.InputParam); err != nil {
+			return nil, err
+		}
+		if err := collect(decl.SqlFuncDecl.OutputParam); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// 使用能整除的最大时间单位构建超时时间的表达式, 如500 * time.Millisecond
+func buildDurationExpr(d time.Duration) ast.Expr {
+	units := []struct {
+		name string
+		unit time.Duration
+	}{
+		{"Hour", time.Hour},
+		{"Minute", time.Minute},
+		{"Second", time.Second},
+		{"Millisecond", time.Millisecond},
+		{"Microsecond", time.Microsecond},
+		{"Nanosecond", time.Nanosecond},
+	}
+	for _, u := range units {
+		if d%u.unit != 0 {
+			continue
+		}
+		unit := astutils.BuildSelectorExpr([]string{timePackageName, u.name})
+		if d == u.unit {
+			return unit
+		}
+		return &ast.BinaryExpr{
+			X:  astutils.BuildBasicLit(token.INT, strconv.FormatInt(int64(d/u.unit), 10)),
+			Op: token.MUL,
+			Y:  unit,
+		}
+	}
+
+	return nil
+}
+
+// 获取sql操作的表名, 动态sql使用第一个sql片段
+func sqlTableName(decl *types.Declaration, sql string) string {
+	if sql == "" {
+		for _, s := range decl.SqlFuncDecl.Sql {
+			if stmt, ok := s.(*types.SimpleStmt); ok {
+				sql = stmt.Sql
+				break
+			}
+		}
+	}
+
+	match := sqlTableRegex.FindStringSubmatch(sql)
+	if len(match) < 2 {
+		return ""
+	}
+
+	return strings.Trim(match[1], "`\"")
+}
+
+// 获取静态sql中敏感参数在Args中的位置
+func sensitiveArgIndexes(params map[string]*types.Param, argNames []string) []int {
+	var res []int
+	for i, name := range argNames {
+		if isSensitiveArg(params, name) {
+			res = append(res, i)
+		}
+	}
+
+	return res
+}
+
+// 参数是否引用了结构体中的敏感字段, 如user.Password
+func isSensitiveArg(params map[string]*types.Param, name string) bool {
+	parts := strings.Split(name, ".")
+	param, ok := params[parts[0]]
+	if !ok || len(parts) < 2 {
+		return false
+	}
+
+	typ := &param.Type
+	for i, fieldName := range parts[1:] {
+		structType := typ.GetValueType()
+		if !structType.IsStruct() {
+			return false
+		}
+
+		var field *types.Param
+		for _, f := range structType.Fields {
+			if f.Name == fieldName {
+				field = f
+				break
+			}
+		}
+		if field == nil {
+			return false
+		}
+		if i == len(parts)-2 {
+			return types.IsSensitiveField(structType, field)
+		}
+		typ = &field.Type
+	}
+
+	return false
+}
+
+// 构建动态sql的参数列表, 敏感参数使用vulcan.Sensitive包装
+func (g *FileGenerator) buildArgExprList(args []string) []ast.Expr {
+	exprs := astutils.BuildIdentOrSelectorExprList(args)
+	for i, arg := range args {
+		if isSensitiveArg(g.argParams, arg) {
+			exprs[i] = astutils.BuildCallExpr(astutils.BuildSelectorExpr([]string{corePackageName, funcNameSensitive}), []ast.Expr{exprs[i]}, false)
+		}
+	}
+
+	return exprs
+}
+
+// 构建更新语句的乐观锁, 参数中没有带有版本字段并且表名与sql操作的表相同的结构体时返回nil
+// &vulcan.OptimisticLock{Column: "version", Version: &user.Version}
+func buildOptimisticLockExpr(decl *types.Declaration, sql string) ast.Expr {
+	if decl.SqlFuncDecl.SQLAnnotation.Name != types.SQLUpdateFunc {
+		return nil
+	}
+
+	table := sqlTableName(decl, sql)
+	if table == "" {
+		return nil
+	}
+
+	names := make([]string, 0, len(decl.SqlFuncDecl.InputParam))
+	for name := range decl.SqlFuncDecl.InputParam {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		typ := &decl.SqlFuncDecl.InputParam[name].Type
+		for typ.IsPointer() && typ.ValueType != nil {
+			typ = typ.ValueType
+		}
+		// 更新其它表时不使用该模型的版本字段
+		if !typ.IsStruct() || !strings.EqualFold(types.TableName(typ), table) {
+			continue
+		}
+		field := types.VersionField(typ)
+		if field == nil {
+			continue
+		}
+
+		column := strings.TrimSpace(strings.Split(field.Type.Tag.Get("db"), ",")[0])
+		return astutils.BuildUnaryExpr("&", &ast.CompositeLit{
+			Type: astutils.BuildSelectorExpr([]string{corePackageName, optimisticLockName}),
+			Elts: []ast.Expr{
+				astutils.BuildKeyValueBasicLitExpr("Column", fmt.Sprintf("%q", column), token.STRING),
+				astutils.BuildKeyValueExpr("Version", astutils.BuildUnaryExpr("&", astutils.BuildIdentOrSelectorExpr(name+"."+field.Name))),
+			},
+		})
+	}
+
+	return nil
+}
@@ -23,14 +23,17 @@ var (
 		Build())
 	return nil
 }`
-	DeleteByIdFuncTemplate = `func ({{ .ReceiverName }} *{{ .MapperName }}) DeleteById({{ .QueryKeyName }} {{ .QueryKeyType }}) {
-	Delete("DELETE FROM {{ .TableName}} WHERE {{ .PrimaryKey }} = {{ .QueryKeyNameRef }}")
+	DeleteByIdFuncTemplate = `func ({{ .ReceiverName }} *{{ .MapperName }}) {{ .FuncName }}({{ .QueryKeyName }} {{ .QueryKeyType }}) {
+	Delete("{{ .DeleteStmt }} WHERE {{ .PrimaryKey }} = {{ .QueryKeyNameRef }}{{ .NotDeletedCondition }}")
 }`
 
-	DeleteBatchIdsFuncTemplate = `func ({{ .ReceiverName }} *{{ .MapperName }}) DeleteBatchIds({{ .QueryListName }} []{{ .QueryKeyType }}) {
+	DeleteBatchIdsFuncTemplate = `func ({{ .ReceiverName }} *{{ .MapperName }}) {{ .FuncName }}({{ .QueryListName }} []{{ .QueryKeyType }}) {
 	Delete(SQL().
-		Stmt("DELETE FROM {{ .TableName }} WHERE {{ .PrimaryKey }} IN").
+		Stmt("{{ .DeleteStmt }} WHERE {{ .PrimaryKey }} IN").
 		Foreach("{{ .QueryListName }}", "{{ .QueryKeyName }}", ", ", "(", ")", "{{ .QueryKeyNameRef }}").
+		{{ if .NotDeletedCondition -}}
+		Stmt("{{ .NotDeletedCondition }}").
+		{{ end -}}
 		Build())
 }`
 
@@ -55,8 +58,8 @@ var (
 	Select("SELECT COUNT(*) FROM {{ .TableName }}")
 }`
 
-	DeleteByFuncTemplate = `func ({{ .ReceiverName }} *{{ .MapperName }}) DeleteBy({{ .ModelObjName }} *{{ .ModelTypeName }}) {
-	Delete("DELETE FROM {{ .TableName }} WHERE 1=1{{ .WhereQuery }}")
+	DeleteByFuncTemplate = `func ({{ .ReceiverName }} *{{ .MapperName }}) {{ .FuncName }}({{ .ModelObjName }} *{{ .ModelTypeName }}) {
+	Delete("{{ .DeleteStmt }} WHERE 1=1{{ .WhereQuery }}{{ .NotDeletedCondition }}")
 }`
 
 	UpdateByIdFuncTemplate = `func ({{ .ReceiverName }} *{{ .MapperName }}) UpdateById({{ .ModelObjName }} *{{ .ModelTypeName }}) {
@@ -67,7 +70,7 @@ var (
 		{{ else -}}
 		Stmt("UPDATE {{ .TableName }} SET {{ .NoValidateSetStmt }}").
 		{{ end -}}
-		Stmt("WHERE {{ .PrimaryKey }} = {{ .QueryKeyNameRef }}").
+		Stmt("WHERE {{ .PrimaryKey }} = {{ .QueryKeyNameRef }}{{ .VersionCondition }}").
 		Build())
 }`
 
@@ -312,6 +315,122 @@ var (
 		return builder.String()
 	}
 
+	// 乐观锁版本字段在更新时自增, 不作为需要更新的列
+	excludeVersionColumn = func(spec *types.ModelSpec, columns []string) []string {
+		if spec.Version == nil {
+			return columns
+		}
+		return stream.Filter(columns, func(column string) bool {
+			return column != spec.Version.ColumnName
+		})
+	}
+
+	// 乐观锁版本号自增, 如version=version+1
+	genVersionSetStmt = func(spec *types.ModelSpec) string {
+		return fmt.Sprintf("%s=%s+1", spec.Version.ColumnName, spec.Version.ColumnName)
+	}
+
+	// 乐观锁版本条件, 如AND version=#{user.Version}
+	genVersionCondition = func(spec *types.ModelSpec, objName string) string {
+		return fmt.Sprintf("AND %s=#{%s.%s}", spec.Version.ColumnName, objName, spec.Version.Name)
+	}
+
+	// 连接多个If注解, 忽略空的注解
+	joinIfAnnotations = func(annotations ...string) string {
+		return strings.Join(stream.Filter(annotations, func(s string) bool {
+			return s != ""
+		}), ".\n\t\t\t")
+	}
+
+	// 更新时自动填充的列总是更新, 如updated_at=#{user.UpdatedAt}
+	genFillSetStmts = func(spec *types.ModelSpec, columns []string, objName string) []string {
+		var res []string
+		for _, field := range spec.ModelFields {
+			if field.IsFillUpdate && !utils.Contains(columns, field.ColumnName) {
+				res = append(res, fmt.Sprintf("%s=#{%s.%s}", field.ColumnName, objName, field.Name))
+			}
+		}
+
+		return res
+	}
+
+	// 生成更新语句的SET子句, 模型中有乐观锁版本字段时版本号自增
+	genUpdateSetStmt = func(spec *types.ModelSpec, funcSpec *types.GenFuncSpec, options *CommonOptions) (setStmt, setAnnotation string, err error) {
+		columns := excludeVersionColumn(spec, funcSpec.SelectColumnNames)
+		fillSets := genFillSetStmts(spec, columns, options.ModelObjName)
+		if !funcSpec.SetValidateEmpty {
+			sets := stream.Map(columns, func(column string) string {
+				return fmt.Sprintf("%s=#{%s.%s}", column, options.ModelObjName, getStructFieldName(column, spec.ModelFields))
+			})
+			sets = append(sets, fillSets...)
+			if spec.Version != nil {
+				sets = append(sets, genVersionSetStmt(spec))
+			}
+			return strings.Join(sets, ","), "", nil
+		}
+
+		if err := validateNullableFields(columns, spec.ModelFields); err != nil {
+			return "", "", err
+		}
+		setAnnotation = genIfOfSetAnnotation(columns, spec.ModelFields, options.ModelObjName)
+		for _, set := range fillSets {
+			setAnnotation = joinIfAnnotations(setAnnotation, fmt.Sprintf("If(true, %q)", set))
+		}
+		if spec.Version != nil {
+			setAnnotation = joinIfAnnotations(setAnnotation, fmt.Sprintf("If(true, %q)", genVersionSetStmt(spec)))
+		}
+
+		return "", setAnnotation, nil
+	}
+
+	// 模型中有逻辑删除字段时, 删除操作更新逻辑删除列, 使用HardDeleteXXX函数进行物理删除
+	newDeleteTemplateOptions = func(spec *types.ModelSpec, funcSpec *types.GenFuncSpec, options *CommonOptions) DeleteTemplateOptions {
+		res := DeleteTemplateOptions{
+			FuncName:   funcSpec.FuncName,
+			DeleteStmt: "DELETE FROM " + options.TableName,
+		}
+		if spec.LogicDelete != nil && !strings.HasPrefix(funcSpec.KeyFuncName, "Hard") {
+			column := spec.LogicDelete.ColumnName
+			res.DeleteStmt = fmt.Sprintf("UPDATE %s SET %s = CURRENT_TIMESTAMP", options.TableName, column)
+			res.NotDeletedCondition = fmt.Sprintf(" AND %s IS NULL", column)
+		}
+
+		return res
+	}
+
+	genDeleteById = func(modelSpec *types.ModelSpec, funcSpec *types.GenFuncSpec, options *CommonOptions) (string, error) {
+		data := &DeleteByIdTemplateOptions{
+			CommonOptions:         options,
+			DeleteTemplateOptions: newDeleteTemplateOptions(modelSpec, funcSpec, options),
+			QueryKeyName:          "id",
+			QueryKeyType:          modelSpec.PrimaryKey.Type,
+			QueryKeyNameRef:       "#{id}",
+		}
+		return utils.ExecuteTemplate("DeleteById", DeleteByIdFuncTemplate, data)
+	}
+
+	genDeleteBatchIds = func(modelSpec *types.ModelSpec, funcSpec *types.GenFuncSpec, options *CommonOptions) (string, error) {
+		data := &DeleteBatchIdsTemplateOptions{
+			CommonOptions:         options,
+			DeleteTemplateOptions: newDeleteTemplateOptions(modelSpec, funcSpec, options),
+			QueryListName:         "ids",
+			QueryKeyType:          modelSpec.PrimaryKey.Type,
+			QueryKeyName:          "id",
+			QueryKeyNameRef:       "#{id}",
+		}
+		return utils.ExecuteTemplate("DeleteBatchIds", DeleteBatchIdsFuncTemplate, data)
+	}
+
+	genDeleteBy = func(spec *types.ModelSpec, funcSpec *types.GenFuncSpec, options *CommonOptions) (string, error) {
+		data := DeleteByTemplateOptions{
+			CommonOptions:         options,
+			DeleteTemplateOptions: newDeleteTemplateOptions(spec, funcSpec, options),
+			WhereQuery:            genWhereQuery(funcSpec.WhereColumnNames, spec.ModelFields, options.ModelObjName),
+		}
+
+		return utils.ExecuteTemplate("DeleteBy", DeleteByFuncTemplate, data)
+	}
+
 	// TODO
 	crudGenFuncMapping = map[string]CRUDGenFunc{
 		"Add": func(modelSpec *types.ModelSpec, funcSpec *types.GenFuncSpec, options *CommonOptions) (string, error) {
@@ -339,25 +458,10 @@ var (
 			}
 			return utils.ExecuteTemplate("AddBatch", AddBatchFuncTemplate, data)
 		},
-		"DeleteById": func(modelSpec *types.ModelSpec, funcSpec *types.GenFuncSpec, options *CommonOptions) (string, error) {
-			data := &DeleteByIdTemplateOptions{
-				CommonOptions:   options,
-				QueryKeyName:    "id",
-				QueryKeyType:    modelSpec.PrimaryKey.Type,
-				QueryKeyNameRef: "#{id}",
-			}
-			return utils.ExecuteTemplate("DeleteById", DeleteByIdFuncTemplate, data)
-		},
-		"DeleteBatchIds": func(modelSpec *types.ModelSpec, funcSpec *types.GenFuncSpec, options *CommonOptions) (string, error) {
-			data := &DeleteBatchIdsTemplateOptions{
-				CommonOptions:   options,
-				QueryListName:   "ids",
-				QueryKeyType:    modelSpec.PrimaryKey.Type,
-				QueryKeyName:    "id",
-				QueryKeyNameRef: "#{id}",
-			}
-			return utils.ExecuteTemplate("DeleteBatchIds", DeleteBatchIdsFuncTemplate, data)
-		},
+		"DeleteById":         genDeleteById,
+		"HardDeleteById":     genDeleteById,
+		"DeleteBatchIds":     genDeleteBatchIds,
+		"HardDeleteBatchIds": genDeleteBatchIds,
 		"SelectById": func(spec *types.ModelSpec, funcSpec *types.GenFuncSpec, options *CommonOptions) (string, error) {
 			data := &SelectByIdTemplateOptions{
 				CommonOptions:   options,
@@ -389,30 +493,26 @@ var (
 		"SelectCount": func(spec *types.ModelSpec, funcSpec *types.GenFuncSpec, options *CommonOptions) (string, error) {
 			return utils.ExecuteTemplate("SelectCount", SelectCountFuncTemplate, options)
 		},
-		"DeleteBy": func(spec *types.ModelSpec, funcSpec *types.GenFuncSpec, options *CommonOptions) (string, error) {
-			data := DeleteByTemplateOptions{
-				CommonOptions: options,
-				WhereQuery:    genWhereQuery(funcSpec.WhereColumnNames, spec.ModelFields, options.ModelObjName),
-			}
-
-			return utils.ExecuteTemplate("DeleteBy", DeleteByFuncTemplate, data)
-		},
+		"DeleteBy":     genDeleteBy,
+		"HardDeleteBy": genDeleteBy,
 		"UpdateById": func(spec *types.ModelSpec, funcSpec *types.GenFuncSpec, options *CommonOptions) (string, error) { // TODO
 			data := &UpdateByIdTemplateOptions{
 				CommonOptions:   options,
 				ValidateEmpty:   funcSpec.SetValidateEmpty,
 				QueryKeyNameRef: fmt.Sprintf("#{%s.%s}", options.ModelObjName, spec.PrimaryKey.Name),
 			}
+			if spec.Version != nil {
+				data.VersionCondition = " " + genVersionCondition(spec, options.ModelObjName)
+			}
 			tmpl := UpdateByIdFuncTemplate
+			setStmt, setAnnotation, err := genUpdateSetStmt(spec, funcSpec, options)
+			if err != nil {
+				return "", err
+			}
 			if !funcSpec.SetValidateEmpty {
-				data.NoValidateSetStmt = strings.Join(stream.Map(funcSpec.SelectColumnNames, func(column string) string {
-					return fmt.Sprintf("%s=#{%s.%s}", column, options.ModelObjName, getStructFieldName(column, spec.ModelFields))
-				}), ",")
+				data.NoValidateSetStmt = setStmt
 			} else {
-				if err := validateNullableFields(funcSpec.SelectColumnNames, spec.ModelFields); err != nil {
-					return "", err
-				}
-				tmpl = fmt.Sprintf(tmpl, genIfOfSetAnnotation(funcSpec.SelectColumnNames, spec.ModelFields, options.ModelObjName))
+				tmpl = fmt.Sprintf(tmpl, setAnnotation)
 			}
 
 			return utils.ExecuteTemplate("UpdateById", tmpl, data)
@@ -426,19 +526,13 @@ var (
 			}
 			var (
 				tmpl            = UpdateByFuncTemplate
-				setAnnotation   string
 				whereAnnotation string
 			)
-			if !funcSpec.SetValidateEmpty {
-				data.NoValidateSetStmt = strings.Join(stream.Map(funcSpec.SelectColumnNames, func(column string) string {
-					return fmt.Sprintf("%s=#{%s.%s}", column, options.ModelObjName, getStructFieldName(column, spec.ModelFields))
-				}), ",")
-			} else {
-				if err := validateNullableFields(funcSpec.SelectColumnNames, spec.ModelFields); err != nil {
-					return "", err
-				}
-				setAnnotation = genIfOfSetAnnotation(funcSpec.SelectColumnNames, spec.ModelFields, options.ModelObjName)
+			setStmt, setAnnotation, err := genUpdateSetStmt(spec, funcSpec, options)
+			if err != nil {
+				return "", err
 			}
+			data.NoValidateSetStmt = setStmt
 
 			if !funcSpec.SelectValidateEmpty {
 				data.WhereQuery = genWhereQuery(funcSpec.WhereColumnNames, spec.ModelFields, options.ModelObjName)
@@ -450,6 +544,13 @@ var (
 				}
 				whereAnnotation = genIfOfWhereAnnotation(funcSpec.WhereColumnNames, spec.ModelFields, options.ModelObjName)
 			}
+			if spec.Version != nil {
+				if !funcSpec.SelectValidateEmpty {
+					data.WhereQuery += " " + genVersionCondition(spec, options.ModelObjName)
+				} else {
+					whereAnnotation = joinIfAnnotations(whereAnnotation, fmt.Sprintf("If(true, %q)", genVersionCondition(spec, options.ModelObjName)))
+				}
+			}
 			tmpl = fmt.Sprintf(tmpl, setAnnotation, whereAnnotation)
 
 			return utils.ExecuteTemplate("UpdateBy", tmpl, data)
@@ -492,8 +593,16 @@ type AddBatchTemplateOptions struct {
 	StructFields   string
 }
 
+// DeleteTemplateOptions 删除函数的公共参数
+type DeleteTemplateOptions struct {
+	FuncName            string
+	DeleteStmt          string // DELETE FROM t_user, 逻辑删除时为UPDATE t_user SET deleted_at = CURRENT_TIMESTAMP
+	NotDeletedCondition string // 逻辑删除时只更新没有删除的行, 如 AND deleted_at IS NULL
+}
+
 type DeleteByIdTemplateOptions struct {
 	*CommonOptions
+	DeleteTemplateOptions
 	QueryKeyName    string
 	QueryKeyType    string
 	QueryKeyNameRef string
@@ -501,6 +610,7 @@ type DeleteByIdTemplateOptions struct {
 
 type DeleteBatchIdsTemplateOptions struct {
 	*CommonOptions
+	DeleteTemplateOptions
 	QueryListName   string
 	QueryKeyType    string
 	QueryKeyName    string
@@ -531,6 +641,7 @@ type SelectAllTemplateOptions struct {
 
 type DeleteByTemplateOptions struct {
 	*CommonOptions
+	DeleteTemplateOptions
 	WhereQuery string
 }
 
@@ -539,6 +650,7 @@ type UpdateByIdTemplateOptions struct {
 	ValidateEmpty     bool
 	NoValidateSetStmt string
 	QueryKeyNameRef   string
+	VersionCondition  string // 乐观锁版本条件
 }
 
 type UpdateByTemplateOptions struct {
@@ -0,0 +1,193 @@
+package dbgenerator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/parser/types"
+)
+
+func TestUpdateTemplateWithVersion(t *testing.T) {
+	version := &types.ModelField{Name: "Version", Type: "int64", ColumnName: "version", IsVersion: true}
+	spec := &types.ModelSpec{
+		ModelFields: []*types.ModelField{
+			{Name: "Id", Type: "int64", ColumnName: "id", IsPrimaryKey: true},
+			{Name: "Name", Type: "string", ColumnName: "name"},
+			{Name: "Nick", Type: "sql.NullString", ColumnName: "nick"},
+			version,
+		},
+		Version: version,
+	}
+	spec.PrimaryKey = spec.ModelFields[0]
+	options := &CommonOptions{
+		MapperName:    "AccountRepo",
+		ReceiverName:  "a",
+		ModelObjName:  "account",
+		ModelTypeName: "model.Account",
+		TableName:     "t_account",
+		PrimaryKey:    "id",
+	}
+
+	tests := []struct {
+		funcSpec *types.GenFuncSpec
+		expected []string
+	}{
+		{
+			// 版本列不作为更新的列
+			funcSpec: &types.GenFuncSpec{FuncName: "UpdateById", KeyFuncName: "UpdateById", SelectColumnNames: []string{"name", "version"}},
+			expected: []string{
+				`Stmt("UPDATE t_account SET name=#{account.Name},version=version+1")`,
+				`Stmt("WHERE id = #{account.Id} AND version=#{account.Version}")`,
+			},
+		},
+		{
+			funcSpec: &types.GenFuncSpec{FuncName: "UpdateById", KeyFuncName: "UpdateById", SelectColumnNames: []string{"nick"}, SetValidateEmpty: true},
+			expected: []string{`If(true, "version=version+1")`, `AND version=#{account.Version}")`},
+		},
+		{
+			funcSpec: &types.GenFuncSpec{
+				FuncName:          "UpdateByName",
+				KeyFuncName:       "UpdateBy",
+				SelectColumnNames: []string{"name"},
+				WhereColumnNames:  []types.Pair[string, string]{{Key: "AND", Val: "name"}},
+			},
+			expected: []string{`Stmt("WHERE 1=1 AND name=#{account.Name} AND version=#{account.Version}")`},
+		},
+		{
+			funcSpec: &types.GenFuncSpec{
+				FuncName:            "UpdateByNick",
+				KeyFuncName:         "UpdateBy",
+				SelectColumnNames:   []string{"nick"},
+				SetValidateEmpty:    true,
+				SelectValidateEmpty: true,
+				WhereColumnNames:    []types.Pair[string, string]{{Key: "AND", Val: "nick"}},
+			},
+			expected: []string{`If(true, "version=version+1")`, `If(true, "AND version=#{account.Version}")`},
+		},
+	}
+	for _, tt := range tests {
+		source, err := crudGenFuncMapping[tt.funcSpec.KeyFuncName](spec, tt.funcSpec, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range tt.expected {
+			if !strings.Contains(source, s) {
+				t.Errorf("%s: %q not found in\n%s", tt.funcSpec.FuncName, s, source)
+			}
+		}
+	}
+}
+
+func TestDeleteTemplateWithLogicDelete(t *testing.T) {
+	deletedAt := &types.ModelField{Name: "DeletedAt", Type: "sql.NullTime", ColumnName: "deleted_at", IsLogicDelete: true}
+	spec := &types.ModelSpec{
+		ModelFields: []*types.ModelField{
+			{Name: "Id", Type: "int64", ColumnName: "id", IsPrimaryKey: true},
+			{Name: "Name", Type: "string", ColumnName: "name"},
+			deletedAt,
+		},
+		LogicDelete: deletedAt,
+	}
+	spec.PrimaryKey = spec.ModelFields[0]
+	options := &CommonOptions{
+		MapperName:    "AccountRepo",
+		ReceiverName:  "a",
+		ModelObjName:  "account",
+		ModelTypeName: "model.Account",
+		TableName:     "t_account",
+		PrimaryKey:    "id",
+	}
+	name := []types.Pair[string, string]{{Key: "AND", Val: "name"}}
+
+	tests := []struct {
+		funcSpec *types.GenFuncSpec
+		expected []string
+	}{
+		{
+			funcSpec: &types.GenFuncSpec{FuncName: "DeleteById", KeyFuncName: "DeleteById"},
+			expected: []string{`Delete("UPDATE t_account SET deleted_at = CURRENT_TIMESTAMP WHERE id = #{id} AND deleted_at IS NULL")`},
+		},
+		{
+			funcSpec: &types.GenFuncSpec{FuncName: "DeleteBatchIds", KeyFuncName: "DeleteBatchIds"},
+			expected: []string{
+				`Stmt("UPDATE t_account SET deleted_at = CURRENT_TIMESTAMP WHERE id IN")`,
+				`Stmt(" AND deleted_at IS NULL")`,
+			},
+		},
+		{
+			funcSpec: &types.GenFuncSpec{FuncName: "DeleteByName", KeyFuncName: "DeleteBy", WhereColumnNames: name},
+			expected: []string{`DeleteByName(account *model.Account)`, `Delete("UPDATE t_account SET deleted_at = CURRENT_TIMESTAMP WHERE 1=1 AND name=#{account.Name} AND deleted_at IS NULL")`},
+		},
+		{
+			funcSpec: &types.GenFuncSpec{FuncName: "HardDeleteById", KeyFuncName: "HardDeleteById"},
+			expected: []string{`HardDeleteById(id int64)`, `Delete("DELETE FROM t_account WHERE id = #{id}")`},
+		},
+		{
+			funcSpec: &types.GenFuncSpec{FuncName: "HardDeleteBatchIds", KeyFuncName: "HardDeleteBatchIds"},
+			expected: []string{`HardDeleteBatchIds(ids []int64)`, `Stmt("DELETE FROM t_account WHERE id IN").`},
+		},
+		{
+			funcSpec: &types.GenFuncSpec{FuncName: "HardDeleteByName", KeyFuncName: "HardDeleteBy", WhereColumnNames: name},
+			expected: []string{`Delete("DELETE FROM t_account WHERE 1=1 AND name=#{account.Name}")`},
+		},
+	}
+	for _, tt := range tests {
+		source, err := crudGenFuncMapping[tt.funcSpec.KeyFuncName](spec, tt.funcSpec, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range tt.expected {
+			if !strings.Contains(source, s) {
+				t.Errorf("%s: %q not found in\n%s", tt.funcSpec.FuncName, s, source)
+			}
+		}
+	}
+}
+
+func TestUpdateTemplateWithFill(t *testing.T) {
+	spec := &types.ModelSpec{
+		ModelFields: []*types.ModelField{
+			{Name: "Id", Type: "int64", ColumnName: "id", IsPrimaryKey: true},
+			{Name: "Name", Type: "sql.NullString", ColumnName: "name"},
+			{Name: "CreatedAt", Type: "time.Time", ColumnName: "created_at", IsFillInsert: true},
+			{Name: "UpdatedAt", Type: "time.Time", ColumnName: "updated_at", IsFillInsert: true, IsFillUpdate: true},
+		},
+	}
+	spec.PrimaryKey = spec.ModelFields[0]
+	options := &CommonOptions{
+		MapperName:    "AccountRepo",
+		ReceiverName:  "a",
+		ModelObjName:  "account",
+		ModelTypeName: "model.Account",
+		TableName:     "t_account",
+		PrimaryKey:    "id",
+	}
+
+	tests := []struct {
+		funcSpec *types.GenFuncSpec
+		expected string
+	}{
+		{
+			funcSpec: &types.GenFuncSpec{FuncName: "UpdateById", KeyFuncName: "UpdateById", SelectColumnNames: []string{"name"}},
+			expected: `Stmt("UPDATE t_account SET name=#{account.Name},updated_at=#{account.UpdatedAt}")`,
+		},
+		{
+			funcSpec: &types.GenFuncSpec{FuncName: "UpdateById", KeyFuncName: "UpdateById", SelectColumnNames: []string{"name"}, SetValidateEmpty: true},
+			expected: `If(true, "updated_at=#{account.UpdatedAt}")`,
+		},
+		{
+			// 已经指定更新的列不会重复更新
+			funcSpec: &types.GenFuncSpec{FuncName: "UpdateById", KeyFuncName: "UpdateById", SelectColumnNames: []string{"updated_at", "name"}},
+			expected: `Stmt("UPDATE t_account SET updated_at=#{account.UpdatedAt},name=#{account.Name}")`,
+		},
+	}
+	for _, tt := range tests {
+		source, err := crudGenFuncMapping[tt.funcSpec.KeyFuncName](spec, tt.funcSpec, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(source, tt.expected) {
+			t.Errorf("%q not found in\n%s", tt.expected, source)
+		}
+	}
+}
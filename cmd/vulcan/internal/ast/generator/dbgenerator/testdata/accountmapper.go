@@ -0,0 +1,48 @@
+//go:build vulcan
+
+package testdata
+
+import (
+	"database/sql"
+	"time"
+
+	. "github.com/mangohow/vulcan/annotation"
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/generator/dbgenerator/testdata/model"
+)
+
+type AccountRepo struct {
+	db *sql.DB
+}
+
+func (m *AccountRepo) Add(account *model.Account) {
+	Insert("INSERT INTO t_account (name, password, email) VALUES (#{account.Name}, #{account.Password}, #{account.Email})")
+}
+
+func (m *AccountRepo) UpdateById(account *model.Account) int64 {
+	Update(SQL().
+		Stmt("UPDATE t_account").
+		Set(If(account.Password != "", "password = #{account.Password}").
+			If(account.Name != "", "name = #{account.Name}")).
+		Stmt("WHERE id = #{account.Id}").
+		Build())
+	return 0
+}
+
+func (m *AccountRepo) AddBatch(accounts []*model.Account) {
+	Insert(SQL().
+		Stmt("INSERT INTO t_account (name, password) VALUES ").
+		Foreach("accounts", "account", ", ", "", "", "(#{account.Name}, #{account.Password})").
+		Build())
+}
+
+func (m *AccountRepo) SelectById(id int64) *model.Account {
+	Select("SELECT id, name, email FROM t_account WHERE id = #{id}")
+	Timeout(500 * time.Millisecond)
+	return nil
+}
+
+// 更新其它表, 不使用Account的乐观锁
+func (m *AccountRepo) UpdateAuditName(account *model.Account) int64 {
+	Update("UPDATE t_account_audit SET name = #{account.Name} WHERE account_id = #{account.Id}")
+	return 0
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/mangohow/vulcan/annotation"
+)
+
+type Account struct {
+	annotation.TableProperty `tableName:"t_account" sensitive:"email" fillInsert:"created_by" timeout:"2s"`
+	Id                       int64        `db:"id,pk"`
+	Name                     string       `db:"name"`
+	Password                 string       `db:"password,sensitive"`
+	Email                    string       `db:"email"`
+	Version                  int64        `db:"version,version"`
+	DeletedAt                sql.NullTime `db:"deleted_at,logic_delete"`
+	CreatedBy                string       `db:"created_by"`
+	UpdatedAt                time.Time    `db:"updated_at,fill_insert_update"`
+}
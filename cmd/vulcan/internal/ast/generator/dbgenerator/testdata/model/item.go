@@ -0,0 +1,6 @@
+package model
+
+type Item struct {
+	Id   int64  `db:"id,pk"`
+	Name string `db:"name"`
+}
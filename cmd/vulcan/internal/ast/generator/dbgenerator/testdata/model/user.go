@@ -0,0 +1,10 @@
+package model
+
+type User struct {
+	Id   int64  `db:"id,pk"`
+	Name string `db:"name"`
+}
+
+type Operator struct {
+	Name string `db:"name"`
+}
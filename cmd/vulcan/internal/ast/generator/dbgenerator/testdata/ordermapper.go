@@ -0,0 +1,22 @@
+//go:build vulcan
+
+package testdata
+
+import (
+	"github.com/mangohow/vulcan"
+	. "github.com/mangohow/vulcan/annotation"
+)
+
+type OrderRepo struct {
+	execer vulcan.Execer
+}
+
+func (m *OrderRepo) DeleteByUser(userId int64) int64 {
+	Delete("DELETE FROM t_order WHERE user_id = #{userId}")
+	return 0
+}
+
+func (m *OrderRepo) CountByUser(userId int64) int {
+	Select("SELECT COUNT(*) FROM t_order WHERE user_id = #{userId}")
+	return 0
+}
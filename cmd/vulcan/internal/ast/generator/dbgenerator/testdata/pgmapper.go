@@ -0,0 +1,23 @@
+//go:build vulcan
+
+package testdata
+
+import (
+	"database/sql"
+
+	. "github.com/mangohow/vulcan/annotation"
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/generator/dbgenerator/testdata/model"
+)
+
+type ItemRepo struct {
+	db *sql.DB
+}
+
+func (m *ItemRepo) Add(item *model.Item) {
+	Insert("INSERT INTO t_item (name) VALUES (#{item.Name})")
+}
+
+func (m *ItemRepo) UpdateName(id int64, name string) int64 {
+	Update("UPDATE t_item SET name = #{name} WHERE id = #{id}")
+	return 0
+}
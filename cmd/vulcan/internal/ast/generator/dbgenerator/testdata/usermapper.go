@@ -0,0 +1,41 @@
+//go:build vulcan
+
+package testdata
+
+import (
+	"database/sql"
+
+	. "github.com/mangohow/vulcan/annotation"
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/generator/dbgenerator/testdata/model"
+)
+
+type UserRepo struct {
+	db *sql.DB
+}
+
+func (m *UserRepo) DeleteById(id int) int {
+	Delete("DELETE FROM t_user WHERE id = #{id}")
+	return 0
+}
+
+func (m *UserRepo) UpdateAge(id int, age int) {
+	Update(SQL().
+		Stmt("UPDATE t_user").
+		Set(If(age > 0, "age = #{age}")).
+		Stmt("WHERE id = #{id}").
+		Build())
+}
+
+func (m *UserRepo) CountByAge(age int) int64 {
+	Select("SELECT COUNT(*) FROM t_user WHERE age = #{age}")
+	return 0
+}
+
+func (m *UserRepo) SelectIdsByAge(age int) []int64 {
+	Select("SELECT id FROM t_user WHERE age = #{age}")
+	return nil
+}
+
+func (m *UserRepo) AddByOperator(operator *model.Operator, user *model.User) {
+	Insert("INSERT INTO t_user (name, created_by) VALUES (#{user.Name}, #{operator.Name})")
+}
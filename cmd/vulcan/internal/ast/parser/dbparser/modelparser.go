@@ -19,6 +19,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -180,6 +181,27 @@ func (p *ModelStructParser) parseStructDecls(typeSpec *ast.TypeSpec) (*types.Mod
 	modelSpec.FuncSpecs = genFuncSpec
 	modelSpec.TableName = tableName
 
+	// TableProperty中指定的敏感列
+	if err := parseSensitiveTag(field0.Tag.Value, modelSpec.ModelName, modelFields); err != nil {
+		return nil, err
+	}
+	// 乐观锁版本列
+	if modelSpec.Version, err = parseVersionTag(field0.Tag.Value, modelSpec.ModelName, modelFields); err != nil {
+		return nil, err
+	}
+	// 逻辑删除列
+	if modelSpec.LogicDelete, err = parseLogicDeleteTag(field0.Tag.Value, modelSpec.ModelName, modelFields); err != nil {
+		return nil, err
+	}
+	// 自动填充列
+	if err := parseFillTag(field0.Tag.Value, modelSpec.ModelName, modelFields); err != nil {
+		return nil, err
+	}
+	// 默认超时时间, 生成的mapper方法在生成sql代码时使用
+	if err := parseTimeoutTag(field0.Tag.Value, modelSpec.ModelName); err != nil {
+		return nil, err
+	}
+
 	return modelSpec, nil
 }
 
@@ -279,6 +301,21 @@ func (p *ModelStructParser) parseColumns(modelName string, fields []*ast.Field)
 		if len(dbTagVals) > 1 && utils.Contains(dbTagVals[1:], "auto_incr") {
 			res.IsAutoIncrement = true
 		}
+		if len(dbTagVals) > 1 && utils.Contains(dbTagVals[1:], types.SensitiveTagOption) {
+			res.IsSensitive = true
+		}
+		if len(dbTagVals) > 1 && utils.Contains(dbTagVals[1:], types.VersionTagOption) {
+			res.IsVersion = true
+		}
+		if len(dbTagVals) > 1 && utils.Contains(dbTagVals[1:], types.LogicDeleteTagOption) {
+			res.IsLogicDelete = true
+		}
+		if len(dbTagVals) > 1 && (utils.Contains(dbTagVals[1:], types.FillInsertTagOption) || utils.Contains(dbTagVals[1:], types.FillInsertUpdateTagOption)) {
+			res.IsFillInsert = true
+		}
+		if len(dbTagVals) > 1 && (utils.Contains(dbTagVals[1:], types.FillUpdateTagOption) || utils.Contains(dbTagVals[1:], types.FillInsertUpdateTagOption)) {
+			res.IsFillUpdate = true
+		}
 
 		return res
 	})
@@ -296,6 +333,130 @@ var (
 	}
 )
 
+func parseSensitiveTag(tag, modelName string, modelFields []*types.ModelField) error {
+	propertyTag := reflect.StructTag(strings.Trim(tag, "`"))
+	for _, column := range types.SplitTagList(propertyTag.Get(types.SensitiveTagKey)) {
+		fields := stream.Filter(modelFields, func(field *types.ModelField) bool {
+			return field.ColumnName == column
+		})
+		if len(fields) == 0 {
+			return errors.Errorf("sensitive column %s not found in model struct %s", column, modelName)
+		}
+		fields[0].IsSensitive = true
+	}
+
+	return nil
+}
+
+// 版本字段支持的类型
+var versionFieldTypes = []string{"int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64"}
+
+func parseVersionTag(tag, modelName string, modelFields []*types.ModelField) (*types.ModelField, error) {
+	propertyTag := reflect.StructTag(strings.Trim(tag, "`"))
+	if column := strings.TrimSpace(propertyTag.Get(types.VersionTagKey)); column != "" {
+		fields := stream.Filter(modelFields, func(field *types.ModelField) bool {
+			return field.ColumnName == column
+		})
+		if len(fields) == 0 {
+			return nil, errors.Errorf("version column %s not found in model struct %s", column, modelName)
+		}
+		fields[0].IsVersion = true
+	}
+
+	versions := stream.Filter(modelFields, func(field *types.ModelField) bool {
+		return field.IsVersion
+	})
+	if len(versions) == 0 {
+		return nil, nil
+	}
+	if len(versions) > 1 {
+		return nil, errors.Errorf("invalid multiple version field in model struct %s", modelName)
+	}
+	if !utils.Contains(versionFieldTypes, versions[0].Type) {
+		return nil, errors.Errorf("version field %s in model struct %s must be an integer, got %s", versions[0].Name, modelName, versions[0].Type)
+	}
+	if versions[0].IsPrimaryKey {
+		return nil, errors.Errorf("version field %s in model struct %s can not be the primary key", versions[0].Name, modelName)
+	}
+
+	return versions[0], nil
+}
+
+func parseLogicDeleteTag(tag, modelName string, modelFields []*types.ModelField) (*types.ModelField, error) {
+	propertyTag := reflect.StructTag(strings.Trim(tag, "`"))
+	if column := strings.TrimSpace(propertyTag.Get(types.LogicDeleteTagKey)); column != "" {
+		fields := stream.Filter(modelFields, func(field *types.ModelField) bool {
+			return field.ColumnName == column
+		})
+		if len(fields) == 0 {
+			return nil, errors.Errorf("logic delete column %s not found in model struct %s", column, modelName)
+		}
+		fields[0].IsLogicDelete = true
+	}
+
+	fields := stream.Filter(modelFields, func(field *types.ModelField) bool {
+		return field.IsLogicDelete
+	})
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if len(fields) > 1 {
+		return nil, errors.Errorf("invalid multiple logic delete field in model struct %s", modelName)
+	}
+	// 使用NULL表示没有删除
+	if !types.IsNullableType(fields[0].Type) {
+		return nil, errors.Errorf("logic delete field %s in model struct %s must be a nullable type, got %s", fields[0].Name, modelName, fields[0].Type)
+	}
+
+	return fields[0], nil
+}
+
+func parseFillTag(tag, modelName string, modelFields []*types.ModelField) error {
+	propertyTag := reflect.StructTag(strings.Trim(tag, "`"))
+	keys := []struct {
+		key            string
+		insert, update bool
+	}{
+		{key: types.FillInsertTagKey, insert: true},
+		{key: types.FillUpdateTagKey, update: true},
+		{key: types.FillInsertUpdateTagKey, insert: true, update: true},
+	}
+	for _, k := range keys {
+		for _, column := range types.SplitTagList(propertyTag.Get(k.key)) {
+			fields := stream.Filter(modelFields, func(field *types.ModelField) bool {
+				return field.ColumnName == column
+			})
+			if len(fields) == 0 {
+				return errors.Errorf("fill column %s not found in model struct %s", column, modelName)
+			}
+			fields[0].IsFillInsert = fields[0].IsFillInsert || k.insert
+			fields[0].IsFillUpdate = fields[0].IsFillUpdate || k.update
+		}
+	}
+
+	// 主键以及乐观锁版本字段由数据库或者vulcan维护, 不能自动填充
+	for _, field := range modelFields {
+		if (field.IsFillInsert || field.IsFillUpdate) && (field.IsPrimaryKey || field.IsVersion) {
+			return errors.Errorf("field %s in model struct %s can't be auto filled", field.Name, modelName)
+		}
+	}
+
+	return nil
+}
+
+func parseTimeoutTag(tag, modelName string) error {
+	propertyTag := reflect.StructTag(strings.Trim(tag, "`"))
+	value := strings.TrimSpace(propertyTag.Get(types.TimeoutTagKey))
+	if value == "" {
+		return nil
+	}
+	if timeout, err := time.ParseDuration(value); err != nil || timeout <= 0 {
+		return errors.Errorf("invalid timeout %q in model struct %s", value, modelName)
+	}
+
+	return nil
+}
+
 func (p *ModelStructParser) parseTablePropertyTag(tag, modelName string, modelFields []*types.ModelField, hasPrimaryKey bool) (string, []*types.GenFuncSpec, error) {
 	// 解析tag
 	propertyTag := reflect.StructTag(strings.Trim(tag, "`"))
@@ -329,7 +490,7 @@ func (p *ModelStructParser) parseTablePropertyTag(tag, modelName string, modelFi
 		if !found {
 			switch funcName {
 			case "Add", "AddBatch", "SelectCount", "SelectAll":
-			case "DeleteById", "DeleteBatchIds", "SelectById", "SelectBatchIds":
+			case "DeleteById", "DeleteBatchIds", "SelectById", "SelectBatchIds", "HardDeleteById", "HardDeleteBatchIds":
 				if !hasPrimaryKey {
 					return "", nil, errors.Errorf("model %s has no primary key field, can not generate funcs select by id", modelName)
 				}
@@ -355,9 +516,9 @@ func (p *ModelStructParser) parseTablePropertyTag(tag, modelName string, modelFi
 			keyFuncName = funcName
 		)
 		switch {
-		case funcName == "DeleteBy":
+		case funcName == "DeleteBy", funcName == "HardDeleteBy":
 			if len(args) != 1 {
-				return "", nil, errors.Errorf("DeleteBy must have 1 parameters")
+				return "", nil, errors.Errorf("%s must have 1 parameters", funcName)
 			}
 			genFuncSpec, err = p.parseGenFuncArgs(args[0], "", "", "", modelFields)
 		case funcName == "UpdateById":
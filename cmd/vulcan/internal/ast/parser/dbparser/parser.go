@@ -6,6 +6,8 @@ import (
 	astparser "go/parser"
 	"go/token"
 	"os"
+	"path"
+	"path/filepath"
 	"reflect"
 	"strings"
 
@@ -21,8 +23,10 @@ import (
 
 const (
 	dbOperatorPackageName = "database/sql"
-	dbOperatorRefName     = "sql"
 	dbOperatorTypeName    = "DB"
+
+	execerPackageName = "github.com/mangohow/vulcan"
+	execerTypeName    = "Execer"
 )
 
 type FileParser struct {
@@ -33,6 +37,8 @@ type FileParser struct {
 	necessaryPackages []string // 必须要导入的包
 	typeParser        *TypeParser
 	typeDeclarations  []*ast.TypeSpec
+	filename          string // 正在解析的文件名
+	lineMapping       []int  // 删除编译指令后每一行在源文件中的行号
 }
 
 func NewFileParser(fst *token.FileSet, dm *parser.DependencyManager) *FileParser {
@@ -44,9 +50,7 @@ func NewFileParser(fst *token.FileSet, dm *parser.DependencyManager) *FileParser
 		},
 		addPackages: []string{
 			"github.com/mangohow/vulcan",
-		},
-		necessaryPackages: []string{
-			dbOperatorPackageName,
+			dbOperatorPackageName, // 生成的代码中会使用sql.Result, receiver使用vulcan.Execer时源文件中可能没有导入
 		},
 		typeParser: NewTypeParser(dm),
 	}
@@ -57,7 +61,8 @@ func (p *FileParser) Parse(filename string) (*types.File, error) {
 	if err != nil {
 		return nil, errors.Errorf("reading file failed, reason: %v", err)
 	}
-	source = utils.TrimLineWithPrefix(source, []byte("//go:build "), []byte("// +build"), []byte("//go:generate"))
+	source, p.lineMapping = utils.TrimLineWithPrefixMapping(source, []byte("//go:build "), []byte("// +build"), []byte("//go:generate"))
+	p.filename = filename
 	f, err := astparser.ParseFile(p.fst, "", source, astparser.ParseComments)
 	if err != nil {
 		return nil, errors.Errorf("parse file %s failed, reason: %s", filename, err)
@@ -166,8 +171,9 @@ func (p *FileParser) parseFuncDeclare(fd *ast.FuncDecl, pkgInfo types.PackageInf
 		InputParam:  make(map[string]*types.Param),
 		OutputParam: make(map[string]*types.Param),
 		FuncName:    fd.Name.Name,
+		Source:      p.sourcePosition(fd.Pos()),
 	}
-	annotationInfos := astutils.FindAnnotationsInFuncBody(types.SQLAnnotationFuncs, types.AnnotationPackageName, fd.Body, pkgInfo)
+	annotationInfos := astutils.FindAnnotationsInFuncBody(types.AnnotationFuncs, types.AnnotationPackageName, fd.Body, pkgInfo)
 	// 如果找不到, 则无需为该函数生成样板代码
 	if len(annotationInfos) == 0 {
 		return nil, nil
@@ -177,6 +183,12 @@ func (p *FileParser) parseFuncDeclare(fd *ast.FuncDecl, pkgInfo types.PackageInf
 	if err := p.validateAnnotation(annotationInfos); err != nil {
 		return nil, err
 	}
+	res.Annotation = annotationInfos
+	for _, info := range annotationInfos {
+		if utils.Contains(types.SQLAnnotationFuncs, info.Name) {
+			res.SQLAnnotation = info
+		}
+	}
 
 	// 解析注解
 	// 1. 先处理接收器
@@ -186,7 +198,7 @@ func (p *FileParser) parseFuncDeclare(fd *ast.FuncDecl, pkgInfo types.PackageInf
 	}
 
 	// 判断接收器是否为结构体, 并且包含sqlx.DB对象
-	if err := p.checkReceiverInvalid(fd.Recv.List[0], res); err != nil {
+	if err := p.checkReceiverInvalid(fd.Recv.List[0], res, pkgInfo); err != nil {
 		return nil, err
 	}
 
@@ -194,6 +206,9 @@ func (p *FileParser) parseFuncDeclare(fd *ast.FuncDecl, pkgInfo types.PackageInf
 	if err := p.parseInputParameter(fd.Type.Params.List, res, pkgInfo); err != nil {
 		return nil, err
 	}
+	if err := p.parseContextParameter(fd.Type.Params.List, res); err != nil {
+		return nil, err
+	}
 
 	// 3. 处理出参
 	var outputFields []*ast.Field
@@ -212,7 +227,7 @@ func (p *FileParser) parseFuncDeclare(fd *ast.FuncDecl, pkgInfo types.PackageInf
 	return res, nil
 }
 
-func (p *FileParser) checkReceiverInvalid(receiver *ast.Field, fnDecl *types.FuncDecl) error {
+func (p *FileParser) checkReceiverInvalid(receiver *ast.Field, fnDecl *types.FuncDecl, pkgInfo types.PackageInfo) error {
 	expr := receiver.Type
 	name := ""
 	fnDecl.Receiver = &types.Param{}
@@ -259,6 +274,29 @@ loop:
 	}
 
 	for _, field := range st.Fields.List {
+		// 也可以使用vulcan.Execer, 比如读写分离的vulcan.Router
+		if se, ok := field.Type.(*ast.SelectorExpr); ok {
+			if refName, ok := packageSelector(se, pkgInfo, execerPackageName, execerTypeName); ok {
+				if len(field.Names) == 0 || field.Names[0].Name == "_" {
+					return errors.Errorf("the vulcan.Execer field of type %s must have a name", name)
+				}
+
+				receiverType.Fields = append(receiverType.Fields, &types.Param{
+					Name: field.Names[0].Name,
+					Type: types.TypeSpec{
+						Name: execerTypeName,
+						Package: &types.PackageInfo{
+							PackageName: refName,
+							PackagePath: execerPackageName,
+						},
+						Kind: reflect.Interface,
+					},
+				})
+
+				return nil
+			}
+		}
+
 		starExpr, ok := field.Type.(*ast.StarExpr)
 		if !ok {
 			continue
@@ -267,7 +305,7 @@ loop:
 		if !ok {
 			continue
 		}
-		if ident, ok := se.X.(*ast.Ident); ok && ident.Name == dbOperatorRefName && se.Sel.Name == dbOperatorTypeName {
+		if refName, ok := packageSelector(se, pkgInfo, dbOperatorPackageName, dbOperatorTypeName); ok {
 			if len(field.Names) == 0 || field.Names[0].Name == "_" {
 				return errors.Errorf("the *sqlx.DB field of type %s must have a name", name)
 			}
@@ -279,7 +317,7 @@ loop:
 					ValueType: &types.TypeSpec{
 						Name: dbOperatorTypeName,
 						Package: &types.PackageInfo{
-							PackageName: dbOperatorRefName,
+							PackageName: refName,
 							PackagePath: dbOperatorPackageName,
 						},
 						Kind: reflect.Struct,
@@ -291,7 +329,7 @@ loop:
 		}
 	}
 
-	return errors.Errorf("type %s must have a field which type is *sqlx.DB", name)
+	return errors.Errorf("type %s must have a field which type is *sql.DB or vulcan.Execer", name)
 }
 
 func genReceiverName(name string) string {
@@ -337,6 +375,24 @@ func (p *FileParser) parseInputParameter(params []*ast.Field, res *types.FuncDec
 	return nil
 }
 
+// 查找context.Context参数, 该参数只能作为第一个参数
+func (p *FileParser) parseContextParameter(params []*ast.Field, res *types.FuncDecl) error {
+	for i, field := range params {
+		for j, name := range field.Names {
+			param, ok := res.InputParam[name.Name]
+			if !ok || !param.Type.IsContext() {
+				continue
+			}
+			if i != 0 || j != 0 {
+				return errors.Errorf("context.Context must be the first parameter")
+			}
+			res.ContextParam = name.Name
+		}
+	}
+
+	return nil
+}
+
 func (p *FileParser) parseFieldExpr(expr ast.Expr, typeParam *types.Param, pkgInfo types.PackageInfo) error {
 	var (
 		typeSpec    = &typeParam.Type
@@ -401,6 +457,17 @@ loop:
 			return errors.Errorf("can't find %s.%s's type declartion", typePkgName, typeName)
 		}
 
+		// context.Context无需解析
+		if found.AbsPackagePath == types.ContextPackagePath && typeName == types.ContextTypeName {
+			typeSpec.Name = typeName
+			typeSpec.Kind = reflect.Interface
+			typeSpec.Package = &types.PackageInfo{
+				PackageName: typePkgName,
+				PackagePath: found.AbsPackagePath,
+			}
+			return nil
+		}
+
 		typeInfo, err = p.typeParser.GetTypeInfo(pkgInfo.FilePath, found.AbsPackagePath, typeName)
 		if err != nil {
 			return errors.Wrapf(err, "type %s.%s invalid", typePkgName, typeName)
@@ -485,6 +552,14 @@ func (p *FileParser) parseAnnotations(fnDecl *types.FuncDecl) error {
 		return err
 	}
 
+	for _, info := range fnDecl.Annotation {
+		if info.Name == types.TimeoutAnnotationFunc {
+			if err := p.parseTimeoutAnnotation(fnDecl, info); err != nil {
+				return err
+			}
+		}
+	}
+
 	// TODO 处理其它注解
 	return nil
 }
@@ -652,6 +727,21 @@ func (p *FileParser) parseDynamicSelectSqlStmt(sqls []types.SQL, fnDecl *types.F
 
 // TODO
 func (p *FileParser) validateAnnotation(annotations []types.AnnotationInfo) error {
+	count, timeoutCount := 0, 0
+	for _, info := range annotations {
+		if utils.Contains(types.SQLAnnotationFuncs, info.Name) {
+			count++
+		}
+		if info.Name == types.TimeoutAnnotationFunc {
+			timeoutCount++
+		}
+	}
+	if count != 1 {
+		return errors.Errorf("func must have exactly one sql annotation of %v", types.SQLAnnotationFuncs)
+	}
+	if timeoutCount > 1 {
+		return errors.Errorf("func must have at most one %s annotation", types.TimeoutAnnotationFunc)
+	}
 
 	return nil
 }
@@ -690,6 +780,24 @@ func parseAllCallExprDepth(expr ast.Expr) []*sqlCall {
 	return res
 }
 
+// 判断选择器表达式是否引用了pkgPath包中的typeName类型, 通过导入路径判断, 支持导入时使用别名
+// 返回源文件中引用该包使用的名称
+func packageSelector(se *ast.SelectorExpr, pkgInfo types.PackageInfo, pkgPath, typeName string) (string, bool) {
+	ident, ok := se.X.(*ast.Ident)
+	if !ok || se.Sel.Name != typeName {
+		return "", false
+	}
+	refName, imported := pkgInfo.ImportsMap[pkgPath]
+	if !imported {
+		return "", false
+	}
+	if refName == "" {
+		refName = path.Base(pkgPath)
+	}
+
+	return refName, ident.Name == refName
+}
+
 // 解析文件导入的包
 func parseImports(af *ast.File, filepath string) types.PackageInfo {
 	pkg := types.PackageInfo{
@@ -727,3 +835,13 @@ func checkNecessaryPackageImport(neededPackages []string, imports []types.Import
 
 	return nil
 }
+
+// 获取声明在源文件中的位置, 格式为file:line
+func (p *FileParser) sourcePosition(pos token.Pos) string {
+	line := p.fst.Position(pos).Line
+	if line > 0 && line <= len(p.lineMapping) {
+		line = p.lineMapping[line-1]
+	}
+
+	return fmt.Sprintf("%s:%d", filepath.Base(p.filename), line)
+}
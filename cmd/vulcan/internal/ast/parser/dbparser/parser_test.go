@@ -3,6 +3,7 @@ package dbparser
 import (
 	"fmt"
 	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/parser/types"
+	"go/ast"
 	"go/parser"
 	"go/token"
 	"log"
@@ -68,3 +69,29 @@ func TestParser(t *testing.T) {
 		fmt.Println()
 	}
 }
+
+func TestPackageSelector(t *testing.T) {
+	tests := []struct {
+		expr    string
+		imports map[string]string
+		refName string
+		match   bool
+	}{
+		{expr: "vulcan.Execer", imports: map[string]string{execerPackageName: ""}, refName: "vulcan", match: true},
+		{expr: "v.Execer", imports: map[string]string{execerPackageName: "v"}, refName: "v", match: true},
+		{expr: "vulcan.Execer", imports: map[string]string{execerPackageName: "v"}},
+		{expr: "vulcan.Execer", imports: map[string]string{"example.com/vulcan": ""}},
+		{expr: "vulcan.DB", imports: map[string]string{execerPackageName: ""}},
+	}
+	for _, tt := range tests {
+		expr, err := parser.ParseExpr(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkgInfo := types.PackageInfo{ImportsMap: tt.imports}
+		refName, ok := packageSelector(expr.(*ast.SelectorExpr), pkgInfo, execerPackageName, execerTypeName)
+		if ok != tt.match || (ok && refName != tt.refName) {
+			t.Errorf("%s with imports %v: got (%q, %v)", tt.expr, tt.imports, refName, ok)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package dbparser
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"time"
+
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/parser/types"
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/errors"
+)
+
+// time包中的时间单位
+var durationUnits = map[string]time.Duration{
+	"Nanosecond":  time.Nanosecond,
+	"Microsecond": time.Microsecond,
+	"Millisecond": time.Millisecond,
+	"Second":      time.Second,
+	"Minute":      time.Minute,
+	"Hour":        time.Hour,
+}
+
+// 解析Timeout注解, 如Timeout(500 * time.Millisecond)
+func (p *FileParser) parseTimeoutAnnotation(fnDecl *types.FuncDecl, info types.AnnotationInfo) error {
+	if len(info.CallExpr.Args) != 1 {
+		return errors.Errorf("annotation %s must have only one parameter", types.TimeoutAnnotationFunc)
+	}
+
+	val, err := evalDurationExpr(info.CallExpr.Args[0])
+	if err != nil {
+		return errors.Wrapf(err, "annotation %s", types.TimeoutAnnotationFunc)
+	}
+	timeout, ok := constant.Int64Val(constant.ToInt(val))
+	if !ok || timeout <= 0 {
+		return errors.Errorf("annotation %s must be a positive integer duration", types.TimeoutAnnotationFunc)
+	}
+	fnDecl.Timeout = time.Duration(timeout)
+
+	return nil
+}
+
+// 计算由数字字面量、time包的时间单位以及四则运算组成的常量表达式
+func evalDurationExpr(expr ast.Expr) (constant.Value, error) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.INT && e.Kind != token.FLOAT {
+			return nil, errors.Errorf("invalid duration literal %s", e.Value)
+		}
+		return constant.MakeFromLiteral(e.Value, e.Kind, 0), nil
+	case *ast.SelectorExpr:
+		if unit, ok := durationUnits[e.Sel.Name]; ok {
+			return constant.MakeInt64(int64(unit)), nil
+		}
+		return nil, errors.Errorf("invalid duration unit %s", e.Sel.Name)
+	case *ast.ParenExpr:
+		return evalDurationExpr(e.X)
+	case *ast.CallExpr:
+		// time.Duration(x)
+		if sel, ok := e.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Duration" && len(e.Args) == 1 {
+			return evalDurationExpr(e.Args[0])
+		}
+	case *ast.BinaryExpr:
+		x, err := evalDurationExpr(e.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := evalDurationExpr(e.Y)
+		if err != nil {
+			return nil, err
+		}
+		switch e.Op {
+		case token.ADD, token.SUB, token.MUL:
+			return constant.BinaryOp(x, e.Op, y), nil
+		case token.QUO:
+			if constant.Sign(y) == 0 {
+				return nil, errors.Errorf("division by zero")
+			}
+			// 整数之间使用整数除法, 与go的常量表达式相同
+			if x.Kind() == constant.Int && y.Kind() == constant.Int {
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y), nil
+			}
+			return constant.BinaryOp(x, token.QUO, y), nil
+		}
+	}
+
+	return nil, errors.Errorf("timeout must be a constant expression like 500 * time.Millisecond")
+}
@@ -0,0 +1,52 @@
+package dbparser
+
+import (
+	"go/ast"
+	astparser "go/parser"
+	"testing"
+	"time"
+
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/ast/parser/types"
+)
+
+func TestParseTimeoutAnnotation(t *testing.T) {
+	tests := []struct {
+		expr    string
+		expect  time.Duration
+		invalid bool
+	}{
+		{expr: "Timeout(500 * time.Millisecond)", expect: 500 * time.Millisecond},
+		{expr: "Timeout(time.Second)", expect: time.Second},
+		{expr: "Timeout(1.5 * time.Second)", expect: 1500 * time.Millisecond},
+		{expr: "Timeout((time.Minute + 30*time.Second) / 2)", expect: 45 * time.Second},
+		{expr: "Timeout(time.Duration(100))", expect: 100},
+		{expr: "Timeout(timeout)", invalid: true},
+		{expr: "Timeout(\"1s\")", invalid: true},
+		{expr: "Timeout(-time.Second)", invalid: true},
+		{expr: "Timeout(0 * time.Second)", invalid: true},
+		{expr: "Timeout(time.Second, time.Second)", invalid: true},
+	}
+	p := &FileParser{}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := astparser.ParseExpr(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fnDecl := &types.FuncDecl{}
+			err = p.parseTimeoutAnnotation(fnDecl, types.AnnotationInfo{CallExpr: expr.(*ast.CallExpr), Name: types.TimeoutAnnotationFunc})
+			if tt.invalid {
+				if err == nil {
+					t.Errorf("expected error, got %s", fnDecl.Timeout)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fnDecl.Timeout != tt.expect {
+				t.Errorf("timeout = %s, expected %s", fnDecl.Timeout, tt.expect)
+			}
+		})
+	}
+}
@@ -50,6 +50,13 @@ var innerType = map[[2]string][]innerTypeInfo{
 			kind:     reflect.Struct,
 		},
 	},
+	// model中嵌入的TableProperty, 解析tag中的配置
+	[2]string{"github.com/mangohow/vulcan/annotation", "annotation"}: {
+		{
+			typeName: types.TablePropertyTypeName,
+			kind:     reflect.Struct,
+		},
+	},
 }
 
 func init() {
@@ -34,6 +34,10 @@ var (
 			"Time":    reflect.Struct,
 			"Value":   reflect.Struct,
 		},
+		// 模型结构体中的TableProperty只用于生成代码, 查询时不会映射到列
+		"github.com/mangohow/vulcan/annotation": {
+			"TableProperty": reflect.Struct,
+		},
 	}
 )
 
@@ -102,10 +102,9 @@ func (m *DependencyManager) loadDependency(filePath, pkgName, structName string)
 		return errors.Wrapf(err, "can't find go.mod")
 	}
 
-	// 配置包加载参数
+	// 配置包加载参数, 只需要目标包的语法树, 不加载依赖包
 	cfg := &packages.Config{
-		Mode: packages.NeedName | packages.NeedFiles |
-			packages.NeedSyntax | packages.NeedTypes,
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
 		Fset: m.fset,
 		Dir:  p,
 	}
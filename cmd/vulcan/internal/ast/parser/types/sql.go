@@ -27,6 +27,12 @@ var SQLAnnotationFuncs = []string{
 	SQLSelectFunc,
 }
 
+// TimeoutAnnotationFunc 指定mapper方法超时时间的注解, 与SQL注解一起使用
+const TimeoutAnnotationFunc = "Timeout"
+
+// AnnotationFuncs 函数体中可以使用的全部注解
+var AnnotationFuncs = append([]string{TimeoutAnnotationFunc}, SQLAnnotationFuncs...)
+
 const (
 	SQLOperateFuncSQL       = "SQL"
 	SQLOperateFuncIf        = "If"
@@ -4,10 +4,36 @@ import (
 	"encoding/json"
 	"go/ast"
 	"reflect"
+	"strings"
+	"time"
 
+	"github.com/mangohow/vulcan/cmd/vulcan/internal/errors"
 	"github.com/mangohow/vulcan/cmd/vulcan/internal/utils/sqlutils"
 )
 
+const (
+	ContextPackagePath = "context"
+	ContextTypeName    = "Context"
+
+	TablePropertyTypeName = "TableProperty"
+	SensitiveTagOption    = "sensitive"    // db标签中标记敏感字段的选项, 如`db:"password,sensitive"`
+	SensitiveTagKey       = "sensitive"    // TableProperty中指定敏感列的标签, 如`sensitive:"password,email"`
+	VersionTagOption      = "version"      // db标签中标记乐观锁版本字段的选项, 如`db:"version,version"`
+	VersionTagKey         = "version"      // TableProperty中指定乐观锁版本列的标签, 如`version:"version"`
+	LogicDeleteTagOption  = "logic_delete" // db标签中标记逻辑删除字段的选项, 如`db:"deleted_at,logic_delete"`
+	LogicDeleteTagKey     = "logicDelete"  // TableProperty中指定逻辑删除列的标签, 如`logicDelete:"deleted_at"`
+	TableNameTagKey       = "tableName"    // TableProperty中指定表名的标签
+	TimeoutTagKey         = "timeout"      // TableProperty中指定表的sql默认超时时间, 如`timeout:"500ms"`
+
+	// 自动填充字段, 插入或更新之前使用vulcan.MetaFillHandler填充, 如`db:"created_at,fill_insert"`或`fillInsert:"created_at"`
+	FillInsertTagOption       = "fill_insert"
+	FillInsertTagKey          = "fillInsert"
+	FillUpdateTagOption       = "fill_update"
+	FillUpdateTagKey          = "fillUpdate"
+	FillInsertUpdateTagOption = "fill_insert_update"
+	FillInsertUpdateTagKey    = "fillInsertUpdate"
+)
+
 type PackageInfo struct {
 	PackageName string // 包名, 短命
 	PackagePath string // 绝对包名
@@ -68,6 +94,9 @@ type FuncDecl struct {
 	SQLAnnotation         AnnotationInfo           // SQL注解 Insert、Delete、Update、Select
 	SelectFields          []string                 // select语句中对应结构体中字段的名称
 	SqlParseResult        *sqlutils.SqlParseResult // 解析出sql中的#{Args}
+	ContextParam          string                   // context.Context参数名称, 为空表示函数没有声明该参数
+	Source                string                   // 函数在源文件中的位置, 格式为file:line
+	Timeout               time.Duration            // Timeout注解指定的超时时间, 为0表示没有指定
 }
 
 // 是否是基本类型
@@ -119,6 +148,11 @@ func (t *TypeSpec) IsInterface() bool {
 	return t.Kind == reflect.Interface
 }
 
+// IsContext 是否是context.Context
+func (t *TypeSpec) IsContext() bool {
+	return t.Kind == reflect.Interface && t.Name == ContextTypeName && t.Package != nil && t.Package.PackagePath == ContextPackagePath
+}
+
 func (t *TypeSpec) GetValueType() *TypeSpec {
 	temp := t
 	for temp.IsPointer() || temp.IsSlice() {
@@ -151,6 +185,8 @@ type ModelSpec struct {
 	FuncSpecs   []*GenFuncSpec
 	ModelFields []*ModelField
 	PrimaryKey  *ModelField
+	Version     *ModelField // 乐观锁版本字段
+	LogicDelete *ModelField // 逻辑删除字段
 }
 
 type ModelField struct {
@@ -159,6 +195,157 @@ type ModelField struct {
 	ColumnName      string // 对应表中列名
 	IsPrimaryKey    bool   // 是否是主键
 	IsAutoIncrement bool   // 是否自增
+	IsSensitive     bool   // 是否为敏感字段, 日志中会隐藏该字段的值
+	IsVersion       bool   // 是否为乐观锁版本字段
+	IsLogicDelete   bool   // 是否为逻辑删除字段, 值为NULL表示没有删除
+	IsFillInsert    bool   // 插入时是否自动填充
+	IsFillUpdate    bool   // 更新时是否自动填充
+}
+
+// IsSensitiveField 结构体字段是否为敏感字段
+// db标签中带有sensitive选项, 或者列名在TableProperty的sensitive标签中
+func IsSensitiveField(structType *TypeSpec, field *Param) bool {
+	return hasColumnOption(structType, field, SensitiveTagOption, SensitiveTagKey)
+}
+
+// IsVersionField 结构体字段是否为乐观锁版本字段
+// db标签中带有version选项, 或者列名为TableProperty的version标签指定的列
+func IsVersionField(structType *TypeSpec, field *Param) bool {
+	return hasColumnOption(structType, field, VersionTagOption, VersionTagKey)
+}
+
+// VersionField 获取结构体的乐观锁版本字段, 没有时返回nil
+func VersionField(structType *TypeSpec) *Param {
+	for _, field := range structType.Fields {
+		if IsVersionField(structType, field) {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// IsLogicDeleteField 结构体字段是否为逻辑删除字段
+// db标签中带有logic_delete选项, 或者列名为TableProperty的logicDelete标签指定的列
+func IsLogicDeleteField(structType *TypeSpec, field *Param) bool {
+	return hasColumnOption(structType, field, LogicDeleteTagOption, LogicDeleteTagKey)
+}
+
+// TableName 获取模型结构体在TableProperty中指定的表名, 没有指定时返回空字符串
+func TableName(structType *TypeSpec) string {
+	for _, field := range structType.Fields {
+		if field.Type.Name == TablePropertyTypeName {
+			return strings.TrimSpace(field.Type.Tag.Get(TableNameTagKey))
+		}
+	}
+
+	return ""
+}
+
+// LogicDeleteColumn 获取模型结构体对应的表名以及逻辑删除列, 没有逻辑删除字段时返回空字符串
+func LogicDeleteColumn(structType *TypeSpec) (table, column string) {
+	for _, field := range structType.Fields {
+		if field.Type.Name == TablePropertyTypeName {
+			table = strings.TrimSpace(field.Type.Tag.Get(TableNameTagKey))
+		}
+	}
+	if table == "" {
+		return "", ""
+	}
+	for _, field := range structType.Fields {
+		if IsLogicDeleteField(structType, field) {
+			return table, strings.TrimSpace(strings.Split(field.Type.Tag.Get("db"), ",")[0])
+		}
+	}
+
+	return "", ""
+}
+
+// TableTimeout 获取模型结构体对应的表名以及TableProperty中指定的超时时间, 没有指定时返回空字符串
+func TableTimeout(structType *TypeSpec) (table string, timeout time.Duration, err error) {
+	for _, field := range structType.Fields {
+		if field.Type.Name != TablePropertyTypeName {
+			continue
+		}
+		table = strings.TrimSpace(field.Type.Tag.Get(TableNameTagKey))
+		value := strings.TrimSpace(field.Type.Tag.Get(TimeoutTagKey))
+		if table == "" || value == "" {
+			return "", 0, nil
+		}
+		timeout, err = time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return "", 0, errors.Errorf("invalid timeout %q of table %s", value, table)
+		}
+		return table, timeout, nil
+	}
+
+	return "", 0, nil
+}
+
+// IsFillField 结构体字段在插入或更新时是否需要自动填充
+func IsFillField(structType *TypeSpec, field *Param, update bool) bool {
+	if hasColumnOption(structType, field, FillInsertUpdateTagOption, FillInsertUpdateTagKey) {
+		return true
+	}
+	if update {
+		return hasColumnOption(structType, field, FillUpdateTagOption, FillUpdateTagKey)
+	}
+
+	return hasColumnOption(structType, field, FillInsertTagOption, FillInsertTagKey)
+}
+
+// FillFields 获取结构体中插入或更新时需要自动填充的字段, Key为列名
+func FillFields(structType *TypeSpec, update bool) []Pair[string, *Param] {
+	var res []Pair[string, *Param]
+	for _, field := range structType.Fields {
+		if IsFillField(structType, field, update) {
+			column := strings.TrimSpace(strings.Split(field.Type.Tag.Get("db"), ",")[0])
+			res = append(res, Pair[string, *Param]{Key: column, Val: field})
+		}
+	}
+
+	return res
+}
+
+// 字段的db标签中带有option选项, 或者列名在TableProperty的key标签中
+func hasColumnOption(structType *TypeSpec, field *Param, option, key string) bool {
+	tagItems := strings.Split(field.Type.Tag.Get("db"), ",")
+	for _, item := range tagItems[1:] {
+		if strings.TrimSpace(item) == option {
+			return true
+		}
+	}
+
+	column := strings.TrimSpace(tagItems[0])
+	if column == "" {
+		return false
+	}
+	for _, f := range structType.Fields {
+		if f.Type.Name != TablePropertyTypeName {
+			continue
+		}
+		for _, c := range SplitTagList(f.Type.Tag.Get(key)) {
+			if c == column {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// SplitTagList 分割使用逗号分隔的标签值
+func SplitTagList(tag string) []string {
+	if strings.TrimSpace(tag) == "" {
+		return nil
+	}
+
+	items := strings.Split(tag, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+
+	return items
 }
 
 type AnnotationInfo struct {
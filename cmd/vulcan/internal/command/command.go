@@ -23,6 +23,8 @@ type CommandOptions struct {
 	RepoSuffix       string `flag:"repo-suffix" default:"Repo" usage:"Specifies the suffix of the generated database access object"`
 	UseNullable      bool   `flag:"use-nullable" default:"true" usage:"When the field can be null, whether to use sql.NullValue as the structure field"`
 	Tags             string `flag:"tags" default:"json" usage:"Add tags to the generated model struct and use a comma to separate it"`
+	Context          bool   `flag:"context" usage:"Add a leading ctx context.Context parameter to the generated mapper methods"`
+	Dialect          string `flag:"dialect" default:"mysql" usage:"Specify the sql dialect of the generated code: [mysql, postgres, sqlite]"`
 }
 
 func BindCommand(cmd *cobra.Command, obj any) (err error) {
@@ -145,11 +145,18 @@ func GetCurrentPackagePath(filename string) (string, error) {
 }
 
 func TrimLineWithPrefix(content []byte, sub ...[]byte) []byte {
+	res, _ := TrimLineWithPrefixMapping(content, sub...)
+	return res
+}
+
+// TrimLineWithPrefixMapping 删除以sub开头的行, 同时返回保留的每一行在原内容中的行号(从1开始)
+func TrimLineWithPrefixMapping(content []byte, sub ...[]byte) ([]byte, []int) {
 	lines := bytes.Split(content, []byte("\n"))
 	buf := bytes.Buffer{}
 	buf.Grow(len(content))
+	mapping := make([]int, 0, len(lines))
 loop:
-	for _, line := range lines {
+	for i, line := range lines {
 		for _, sb := range sub {
 			line = bytes.TrimLeft(line, " ")
 			if bytes.HasPrefix(line, sb) {
@@ -158,9 +165,10 @@ loop:
 		}
 		buf.Write(line)
 		buf.WriteByte('\n')
+		mapping = append(mapping, i+1)
 	}
 
-	return buf.Bytes()
+	return buf.Bytes(), mapping
 }
 
 // IsDirExists 判断指定路径是否为存在的目录
@@ -54,7 +54,7 @@ var (
 				return
 			}
 
-			if err := generateMapper(options.File); err != nil {
+			if err := generateMapper(options.File, options); err != nil {
 				log.Fatalf("%v", err)
 			}
 		},
@@ -95,7 +95,7 @@ func generateMapperByStructModel(options *command.CommandOptions) error {
 
 	// 生成最终mapper代码
 	for _, file := range files {
-		if err := generateMapper(file); err != nil {
+		if err := generateMapper(file, options); err != nil {
 			return err
 		}
 	}
@@ -192,7 +192,7 @@ func main() {
 	}
 }
 
-func generateMapper(path string) error {
+func generateMapper(path string, options *command.CommandOptions) error {
 	fst := token.NewFileSet()
 	dependencyManager := parser2.NewDependencyManager(fst)
 	parser := dbparser.NewFileParser(fst, dependencyManager)
@@ -203,7 +203,7 @@ func generateMapper(path string) error {
 
 	idx := strings.LastIndex(path, ".")
 	newFileName := path[:idx] + "_gen" + path[idx:]
-	generator := dbgenerator.NewFileGenerator(parsedFile)
+	generator := dbgenerator.NewFileGenerator(parsedFile, options)
 	if err := generator.Execute(newFileName); err != nil {
 		return errors.Wrapf(err, "generate file %s failed", path)
 	}
@@ -0,0 +1,175 @@
+package vulcan
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// DefaultDataSource 默认数据源名称, OpenMysql打开的数据库会注册为默认数据源
+const DefaultDataSource = "default"
+
+type dataSource struct {
+	name    string
+	db      *sql.DB // 开启事务使用的数据库
+	execer  Execer  // 执行sql使用的Execer
+	dialect Dialect // 数据源的方言, 为nil时使用DefaultDialect
+}
+
+var (
+	dataSourceMu sync.RWMutex
+	dataSources  = make(map[string]*dataSource)
+)
+
+// Register 注册一个数据源, 名称相同时会覆盖之前注册的数据源
+func Register(name string, db *sql.DB) {
+	register(name, db, db)
+}
+
+func register(name string, db *sql.DB, execer Execer) {
+	if name == "" {
+		name = DefaultDataSource
+	}
+
+	dataSourceMu.Lock()
+	defer dataSourceMu.Unlock()
+	dataSources[name] = &dataSource{
+		name:   name,
+		db:     db,
+		execer: execer,
+	}
+}
+
+// Deregister 删除一个数据源, 不会关闭数据库连接
+func Deregister(name string) {
+	dataSourceMu.Lock()
+	defer dataSourceMu.Unlock()
+	delete(dataSources, name)
+}
+
+// SetDialect 设置数据源的方言
+func SetDialect(name string, dialect Dialect) error {
+	if name == "" {
+		name = DefaultDataSource
+	}
+
+	dataSourceMu.Lock()
+	defer dataSourceMu.Unlock()
+	ds, ok := dataSources[name]
+	if !ok {
+		return fmt.Errorf("data source %q is not registered", name)
+	}
+	ds.dialect = dialect
+
+	return nil
+}
+
+// DataSource 根据名称获取注册的数据库
+func DataSource(name string) (*sql.DB, bool) {
+	ds, err := getDataSource(name)
+	if err != nil {
+		return nil, false
+	}
+
+	return ds.db, true
+}
+
+func getDataSource(name string) (*dataSource, error) {
+	if name == "" {
+		name = DefaultDataSource
+	}
+
+	dataSourceMu.RLock()
+	ds, ok := dataSources[name]
+	dataSourceMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("data source %q is not registered", name)
+	}
+
+	return ds, nil
+}
+
+// 根据名称查找数据源, 没有名称时查找Execer所属的数据源
+func findDataSource(name string, execer Execer) *dataSource {
+	if name != "" {
+		ds, _ := getDataSource(name)
+		return ds
+	}
+
+	dataSourceMu.RLock()
+	defer dataSourceMu.RUnlock()
+	for _, ds := range dataSources {
+		if ds.execer == execer || Execer(ds.db) == execer {
+			return ds
+		}
+	}
+
+	return nil
+}
+
+// WithDataSource 指定sql在哪个数据源上执行, 在事务中执行时该选项不生效
+func WithDataSource(name string) Option {
+	return func(o *ExecOption) {
+		o.DataSource = name
+	}
+}
+
+// 根据数据源名称确定Execer
+// 1. 已经在事务中执行, 不做处理
+// 2. context中存在事务, 且Execer为空或属于该事务的数据源, 使用该事务
+// 3. 指定了数据源, 使用该数据源
+// 4. 没有指定Execer, 使用默认数据源
+func (e *ExecOption) resolveExecer() error {
+	if _, ok := e.Execer.(*sql.Tx); ok {
+		return nil
+	}
+
+	if txc := e.ambientTransaction(); txc != nil {
+		e.Execer = txc.tx
+		e.DataSource = txc.dataSource
+		return nil
+	}
+
+	if e.DataSource == "" && !isNilExecer(e.Execer) {
+		return nil
+	}
+
+	ds, err := getDataSource(e.DataSource)
+	if err != nil {
+		return err
+	}
+	e.Execer = ds.execer
+
+	return nil
+}
+
+func isNilExecer(execer Execer) bool {
+	if execer == nil {
+		return true
+	}
+
+	db, ok := execer.(*sql.DB)
+	return ok && db == nil
+}
+
+// 获取context中可以使用的事务
+func (e *ExecOption) ambientTransaction() *txContext {
+	txc, ok := e.Context().Value(txKey{}).(*txContext)
+	if !ok {
+		return nil
+	}
+	if e.DataSource != "" && txc.dataSource != "" && e.DataSource != txc.dataSource {
+		return nil
+	}
+	if isNilExecer(e.Execer) {
+		return txc
+	}
+
+	// 生成的代码中Execer为mapper的db, 属于事务的数据源时使用该事务
+	ds, err := getDataSource(txc.dataSource)
+	if err != nil || (ds.execer != e.Execer && Execer(ds.db) != e.Execer) {
+		return nil
+	}
+
+	return txc
+}
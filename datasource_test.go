@@ -0,0 +1,70 @@
+package vulcan
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestTransactionalOn(t *testing.T) {
+	orderDB, orderState := openFakeDB(t)
+	userDB, userState := openFakeDB(t)
+	Register("order", orderDB)
+	Register("user", userDB)
+	defer Deregister("order")
+	defer Deregister("user")
+
+	err := TransactionalOn("order", func(opts ...Option) error {
+		option := (&ExecOption{SqlStmt: "DELETE FROM t_order", Execer: userDB}).Apply(opts...)
+		_, err := Invoke(option, func() (sql.Result, error) {
+			return option.Exec()
+		})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"BEGIN", "DELETE FROM t_order", "COMMIT"}
+	if got := orderState.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	if got := userState.Logs(); len(got) != 0 {
+		t.Fatalf("unexpected statements on user data source: %v", got)
+	}
+
+	if err := TransactionalOn("unknown", func(opts ...Option) error { return nil }); err == nil {
+		t.Fatal("expected error for unregistered data source")
+	}
+}
+
+func TestWithDataSource(t *testing.T) {
+	defaultDB, defaultState := openFakeDB(t)
+	reportDB, reportState := openFakeDB(t)
+	Register(DefaultDataSource, defaultDB)
+	Register("report", reportDB)
+	defer Deregister(DefaultDataSource)
+	defer Deregister("report")
+
+	exec := func(execer Execer, opts ...Option) {
+		option := (&ExecOption{SqlStmt: "UPDATE t_user SET age = 1", Execer: execer}).Apply(opts...)
+		if _, err := Invoke(option, func() (sql.Result, error) {
+			return option.Exec()
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 没有设置Execer时使用默认数据源
+	var db *sql.DB
+	exec(db)
+	// WithDataSource覆盖mapper中的数据库
+	exec(defaultDB, WithDataSource("report"))
+
+	if got := defaultState.Logs(); len(got) != 1 {
+		t.Fatalf("expected 1 statement on default data source, got %v", got)
+	}
+	if got := reportState.Logs(); len(got) != 1 {
+		t.Fatalf("expected 1 statement on report data source, got %v", got)
+	}
+}
@@ -0,0 +1,230 @@
+package vulcan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect 数据库方言, 生成的代码和拦截器中统一使用?作为占位符, 执行时由方言转换
+type Dialect interface {
+	// Name 方言名称, 如mysql、postgres、sqlite
+	Name() string
+	// Rebind 将sql中的?占位符转换为方言使用的占位符
+	Rebind(query string) string
+	// Quote 引用表名、列名等标识符
+	Quote(identifier string) string
+	// Returning 生成返回指定列的子句, 不支持RETURNING时返回空字符串
+	Returning(columns ...string) string
+	// LimitOffset 生成分页子句
+	LimitOffset(limit, offset int) string
+}
+
+var (
+	// MySQL mysql方言
+	MySQL Dialect = mysqlDialect{}
+	// Postgres postgresql方言, 占位符为$1, $2...
+	Postgres Dialect = postgresDialect{}
+	// SQLite sqlite方言
+	SQLite Dialect = sqliteDialect{}
+
+	// DefaultDialect 无法确定数据源的方言时使用的方言
+	DefaultDialect = MySQL
+)
+
+var dialects = map[string]Dialect{
+	"mysql":    MySQL,
+	"postgres": Postgres,
+	"sqlite":   SQLite,
+}
+
+// GetDialect 根据名称获取方言
+func GetDialect(name string) (Dialect, bool) {
+	d, ok := dialects[strings.ToLower(name)]
+	return d, ok
+}
+
+// WithDialect 指定执行sql时使用的方言, 优先级高于数据源的方言
+func WithDialect(dialect Dialect) Option {
+	return func(o *ExecOption) {
+		o.Dialect = dialect
+	}
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (mysqlDialect) Rebind(query string) string {
+	return query
+}
+
+func (mysqlDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier, '`')
+}
+
+func (mysqlDialect) Returning(columns ...string) string {
+	return ""
+}
+
+func (mysqlDialect) LimitOffset(limit, offset int) string {
+	return limitOffset(limit, offset)
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) Rebind(query string) string {
+	return rebindDollar(query)
+}
+
+func (postgresDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier, '"')
+}
+
+func (d postgresDialect) Returning(columns ...string) string {
+	return returning(d, columns)
+}
+
+func (postgresDialect) LimitOffset(limit, offset int) string {
+	return limitOffset(limit, offset)
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) Rebind(query string) string {
+	return query
+}
+
+func (sqliteDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier, '"')
+}
+
+func (d sqliteDialect) Returning(columns ...string) string {
+	return returning(d, columns)
+}
+
+func (sqliteDialect) LimitOffset(limit, offset int) string {
+	return limitOffset(limit, offset)
+}
+
+func limitOffset(limit, offset int) string {
+	if offset <= 0 {
+		return fmt.Sprintf("LIMIT %d", limit)
+	}
+
+	return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+}
+
+func returning(d Dialect, columns []string) string {
+	if len(columns) == 0 {
+		return ""
+	}
+
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = d.Quote(c)
+	}
+
+	return " RETURNING " + strings.Join(quoted, ", ")
+}
+
+// 引用标识符, 带有.的标识符会分别引用, 如t.id
+func quoteIdentifier(identifier string, quote byte) string {
+	q := string(quote)
+	parts := strings.Split(identifier, ".")
+	for i, p := range parts {
+		if p == "*" {
+			continue
+		}
+		parts[i] = q + strings.ReplaceAll(p, q, q+q) + q
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// 将?替换为$1, $2..., 字符串、引用的标识符以及注释中的?不会被替换
+func rebindDollar(query string) string {
+	if strings.IndexByte(query, '?') < 0 {
+		return query
+	}
+
+	var (
+		builder strings.Builder
+		n       = 0
+		quote   byte
+		comment byte // '-'为行注释, '*'为块注释
+	)
+	builder.Grow(len(query) + 8)
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case comment == '-':
+			if c == '\n' {
+				comment = 0
+			}
+		case comment == '*':
+			if c == '*' && i+1 < len(query) && query[i+1] == '/' {
+				builder.WriteString("*/")
+				i++
+				comment = 0
+				continue
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			comment = '-'
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			builder.WriteString("/*")
+			i++
+			comment = '*'
+			continue
+		case c == '?':
+			n++
+			builder.WriteByte('$')
+			builder.WriteString(strconv.Itoa(n))
+			continue
+		}
+		builder.WriteByte(c)
+	}
+
+	return builder.String()
+}
+
+// 确定执行sql使用的方言
+// 1. 通过WithDialect指定的方言
+// 2. 数据源注册的方言
+// 3. DefaultDialect
+func (e *ExecOption) resolveDialect() {
+	if e.Dialect != nil {
+		return
+	}
+
+	if ds := findDataSource(e.DataSource, e.Execer); ds != nil && ds.dialect != nil {
+		e.Dialect = ds.dialect
+		return
+	}
+	e.Dialect = DefaultDialect
+}
+
+// 获取执行时使用的sql语句
+func (e *ExecOption) query() string {
+	if e.Dialect == nil {
+		return e.SqlStmt
+	}
+
+	return e.Dialect.Rebind(e.SqlStmt)
+}
@@ -0,0 +1,104 @@
+package vulcan
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestDialectRebind(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		query    string
+		expected string
+	}{
+		{MySQL, "SELECT * FROM t_user WHERE id = ? AND name = ?", "SELECT * FROM t_user WHERE id = ? AND name = ?"},
+		{SQLite, "SELECT * FROM t_user WHERE id = ?", "SELECT * FROM t_user WHERE id = ?"},
+		{Postgres, "SELECT * FROM t_user WHERE id = ? AND name = ?", "SELECT * FROM t_user WHERE id = $1 AND name = $2"},
+		{Postgres, "SELECT '?', \"a?\" FROM t_user WHERE id = ?", "SELECT '?', \"a?\" FROM t_user WHERE id = $1"},
+		{Postgres, "SELECT * FROM t_user -- id = ?\nWHERE id = ?", "SELECT * FROM t_user -- id = ?\nWHERE id = $1"},
+		{Postgres, "SELECT /* name = ? */ * FROM t_user WHERE id = ? /*/ ? */ AND name = ?", "SELECT /* name = ? */ * FROM t_user WHERE id = $1 /*/ ? */ AND name = $2"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dialect.Rebind(tt.query); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.dialect.Name(), tt.expected, got)
+		}
+	}
+}
+
+func TestDialectQuoteAndReturning(t *testing.T) {
+	if got := MySQL.Quote("t.id"); got != "`t`.`id`" {
+		t.Errorf("unexpected mysql quote %s", got)
+	}
+	if got := Postgres.Quote(`a"b`); got != `"a""b"` {
+		t.Errorf("unexpected postgres quote %s", got)
+	}
+	if got := MySQL.Returning("id"); got != "" {
+		t.Errorf("mysql does not support returning, got %s", got)
+	}
+	if got := SQLite.Returning("id"); got != ` RETURNING "id"` {
+		t.Errorf("unexpected sqlite returning %s", got)
+	}
+	if got := Postgres.LimitOffset(10, 0); got != "LIMIT 10" {
+		t.Errorf("unexpected limit %s", got)
+	}
+}
+
+func TestDataSourceDialect(t *testing.T) {
+	state := setupTxTest(t)
+	if err := SetDialect(DefaultDataSource, Postgres); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Transactional(func(opts ...Option) error {
+		return execStmt("UPDATE t_user SET age = ? WHERE id = ?", opts...)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// WithDialect优先级高于数据源的方言
+	if err := execStmt("DELETE FROM t_user WHERE id = ?", WithDialect(MySQL)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"BEGIN", "UPDATE t_user SET age = $1 WHERE id = $2", "COMMIT", "DELETE FROM t_user WHERE id = ?"}
+	if got := state.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	if err := SetDialect("unknown", SQLite); err == nil {
+		t.Fatal("expected error for unregistered data source")
+	}
+}
+
+func TestExecReturning(t *testing.T) {
+	db, state := openFakeDB(t)
+	state.rowsFunc = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"id"}, [][]driver.Value{{int64(7)}, {int64(8)}}
+	}
+
+	option := &ExecOption{
+		SqlStmt: "INSERT INTO t_user (username) VALUES (?), (?) RETURNING id",
+		Args:    []any{"a", "b"},
+		Execer:  db,
+		Dialect: Postgres,
+	}
+	result, err := Invoke(option, func() (sql.Result, error) {
+		return option.ExecReturning()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, _ := result.LastInsertId()
+	affected, _ := result.RowsAffected()
+	if id != 7 || affected != 2 {
+		t.Fatalf("expected id 7 and 2 rows affected, got %d, %d", id, affected)
+	}
+	expected := []string{"INSERT INTO t_user (username) VALUES ($1), ($2) RETURNING id"}
+	if got := state.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,212 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysql错误码
+const (
+	mysqlErrDupEntry         = 1062 // Duplicate entry for key
+	mysqlErrRowIsReferenced  = 1451 // Cannot delete or update a parent row: a foreign key constraint fails
+	mysqlErrNoReferencedRow  = 1452 // Cannot add or update a child row: a foreign key constraint fails
+	mysqlErrLockWaitTimeout  = 1205 // Lock wait timeout exceeded
+	mysqlErrLockDeadlock     = 1213 // Deadlock found when trying to get lock
+	mysqlErrQueryInterrupted = 3024 // Query execution was interrupted, maximum statement execution time exceeded
+)
+
+// postgresql错误码(SQLSTATE)
+const (
+	pgErrUniqueViolation     = "23505"
+	pgErrForeignKeyViolation = "23503"
+	pgErrDeadlockDetected    = "40P01"
+	pgErrLockNotAvailable    = "55P03"
+	pgErrQueryCanceled       = "57014"
+)
+
+// 数据库错误分类, 可以使用errors.Is判断Invoke返回的错误
+var (
+	// ErrNotFound 查询单条记录时没有结果
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicateKey 违反主键或唯一索引约束
+	ErrDuplicateKey = errors.New("duplicate key")
+	// ErrForeignKey 违反外键约束
+	ErrForeignKey = errors.New("foreign key constraint violation")
+	// ErrDeadlock 发生死锁, 事务已经被数据库回滚
+	ErrDeadlock = errors.New("deadlock detected")
+	// ErrTimeout 锁等待超时或sql执行超时
+	ErrTimeout = errors.New("query timeout")
+	// ErrOptimisticLock 使用乐观锁更新时没有更新任何行, 记录已经被修改或删除, 或者记录不存在
+	ErrOptimisticLock = errors.New("optimistic lock conflict")
+	// ErrOverloaded 等待执行超时或者数据源的熔断器已经打开
+	ErrOverloaded = errors.New("database overloaded")
+)
+
+// QueryError Invoke执行sql失败时返回的错误
+type QueryError struct {
+	Mapper string
+	Method string
+	Table  string
+	SQL    string
+	Kind   error // 错误分类, 如ErrNotFound, 无法分类时为nil
+	Err    error // 原始错误
+}
+
+func (e *QueryError) Error() string {
+	builder := strings.Builder{}
+	if e.Mapper != "" || e.Method != "" {
+		builder.WriteString(e.Mapper)
+		if e.Mapper != "" && e.Method != "" {
+			builder.WriteString(".")
+		}
+		builder.WriteString(e.Method)
+		builder.WriteString(": ")
+	}
+	if e.Kind != nil {
+		builder.WriteString(e.Kind.Error())
+		builder.WriteString(": ")
+	}
+	builder.WriteString(e.Err.Error())
+	if e.Table != "" {
+		builder.WriteString(fmt.Sprintf(" [table: %s]", e.Table))
+	}
+
+	return builder.String()
+}
+
+// Is 使errors.Is可以判断错误分类
+func (e *QueryError) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorClassifier 将驱动返回的错误转换为错误分类, 方言可以实现该接口
+type ErrorClassifier interface {
+	// ClassifyError 返回错误对应的分类, 无法分类时返回nil
+	ClassifyError(err error) error
+}
+
+// ClassifyError 获取错误的分类, 无法分类时返回nil
+// dialect为nil时依次使用内置的方言分类
+func ClassifyError(dialect Dialect, err error) error {
+	var queryErr *QueryError
+	if errors.As(err, &queryErr) && queryErr.Kind != nil {
+		return queryErr.Kind
+	}
+
+	var timeoutErr *statementTimeoutError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &timeoutErr):
+		return ErrTimeout
+	}
+
+	if dialect != nil {
+		if classifier, ok := dialect.(ErrorClassifier); ok {
+			return classifier.ClassifyError(err)
+		}
+		return nil
+	}
+
+	for _, d := range []Dialect{MySQL, Postgres, SQLite} {
+		if kind := d.(ErrorClassifier).ClassifyError(err); kind != nil {
+			return kind
+		}
+	}
+
+	return nil
+}
+
+func (mysqlDialect) ClassifyError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return nil
+	}
+
+	switch mysqlErr.Number {
+	case mysqlErrDupEntry:
+		return ErrDuplicateKey
+	case mysqlErrRowIsReferenced, mysqlErrNoReferencedRow:
+		return ErrForeignKey
+	case mysqlErrLockDeadlock:
+		return ErrDeadlock
+	case mysqlErrLockWaitTimeout, mysqlErrQueryInterrupted:
+		return ErrTimeout
+	}
+
+	return nil
+}
+
+// lib/pq和pgx的错误都实现了该接口
+type sqlStateError interface {
+	SQLState() string
+}
+
+func (postgresDialect) ClassifyError(err error) error {
+	var pgErr sqlStateError
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+
+	switch pgErr.SQLState() {
+	case pgErrUniqueViolation:
+		return ErrDuplicateKey
+	case pgErrForeignKeyViolation:
+		return ErrForeignKey
+	case pgErrDeadlockDetected:
+		return ErrDeadlock
+	case pgErrLockNotAvailable, pgErrQueryCanceled:
+		return ErrTimeout
+	}
+
+	return nil
+}
+
+// sqlite驱动的错误类型各不相同, 根据错误信息判断
+func (sqliteDialect) ClassifyError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "UNIQUE constraint failed"), strings.Contains(msg, "PRIMARY KEY constraint failed"):
+		return ErrDuplicateKey
+	case strings.Contains(msg, "FOREIGN KEY constraint failed"):
+		return ErrForeignKey
+	case strings.Contains(msg, "database is locked"), strings.Contains(msg, "database table is locked"):
+		return ErrTimeout
+	}
+
+	return nil
+}
+
+// 将执行sql的错误包装为QueryError
+func (e *ExecOption) wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var queryErr *QueryError
+	if errors.As(err, &queryErr) {
+		return err
+	}
+
+	err = e.timeoutError(err)
+	queryErr = &QueryError{
+		SQL:  e.SqlStmt,
+		Kind: ClassifyError(e.Dialect, err),
+		Err:  err,
+	}
+	if e.Meta != nil {
+		queryErr.Mapper = e.Meta.Mapper
+		queryErr.Method = e.Meta.Method
+		queryErr.Table = e.Meta.Table
+	}
+
+	return queryErr
+}
@@ -0,0 +1,107 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type pgError struct {
+	code string
+}
+
+func (e *pgError) Error() string {
+	return "pq: " + e.code
+}
+
+func (e *pgError) SQLState() string {
+	return e.code
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		err      error
+		expected error
+	}{
+		{MySQL, sql.ErrNoRows, ErrNotFound},
+		{MySQL, fmt.Errorf("scan: %w", sql.ErrNoRows), ErrNotFound},
+		{MySQL, context.DeadlineExceeded, ErrTimeout},
+		{MySQL, &mysql.MySQLError{Number: mysqlErrDupEntry}, ErrDuplicateKey},
+		{MySQL, &mysql.MySQLError{Number: mysqlErrNoReferencedRow}, ErrForeignKey},
+		{MySQL, &mysql.MySQLError{Number: mysqlErrLockDeadlock}, ErrDeadlock},
+		{MySQL, &mysql.MySQLError{Number: mysqlErrLockWaitTimeout}, ErrTimeout},
+		{MySQL, &mysql.MySQLError{Number: 1064}, nil},
+		{MySQL, &pgError{code: pgErrUniqueViolation}, nil},
+		{Postgres, &pgError{code: pgErrUniqueViolation}, ErrDuplicateKey},
+		{Postgres, &pgError{code: pgErrForeignKeyViolation}, ErrForeignKey},
+		{Postgres, &pgError{code: pgErrDeadlockDetected}, ErrDeadlock},
+		{Postgres, &pgError{code: pgErrQueryCanceled}, ErrTimeout},
+		{SQLite, errors.New("UNIQUE constraint failed: t_user.username"), ErrDuplicateKey},
+		{SQLite, errors.New("database is locked"), ErrTimeout},
+		{nil, &pgError{code: pgErrDeadlockDetected}, ErrDeadlock},
+		{nil, errors.New("unknown"), nil},
+	}
+
+	for _, tt := range tests {
+		if got := ClassifyError(tt.dialect, tt.err); got != tt.expected {
+			t.Errorf("classify %v: expected %v, got %v", tt.err, tt.expected, got)
+		}
+	}
+}
+
+func TestInvokeQueryError(t *testing.T) {
+	db, state := openFakeDB(t)
+	state.errFunc = func(query string) error {
+		return &mysql.MySQLError{Number: mysqlErrDupEntry, Message: "Duplicate entry '1' for key 'PRIMARY'"}
+	}
+
+	option := &ExecOption{
+		SqlStmt: "INSERT INTO t_user (id) VALUES (?)",
+		Args:    []any{1},
+		Execer:  db,
+		Meta:    &StatementMeta{Mapper: "UserMapper", Method: "Add", Table: "t_user"},
+	}
+	_, err := Invoke(option, func() (sql.Result, error) {
+		return option.Exec()
+	})
+
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) {
+		t.Fatalf("expected QueryError, got %v", err)
+	}
+	if !errors.Is(err, ErrDuplicateKey) || errors.Is(err, ErrNotFound) {
+		t.Fatalf("unexpected error kind: %v", err)
+	}
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		t.Fatal("expected driver error to be preserved")
+	}
+	if queryErr.Mapper != "UserMapper" || queryErr.Method != "Add" || queryErr.Table != "t_user" || queryErr.SQL != option.SqlStmt {
+		t.Fatalf("unexpected query error %+v", queryErr)
+	}
+	expected := "UserMapper.Add: duplicate key: Error 1062: Duplicate entry '1' for key 'PRIMARY' [table: t_user]"
+	if err.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, err.Error())
+	}
+
+	// 没有查询到记录
+	state.errFunc = nil
+	state.rowsFunc = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"id"}, nil
+	}
+	option = &ExecOption{SqlStmt: "SELECT id FROM t_user WHERE id = ?", Args: []any{1}, Execer: db}
+	_, err = Invoke(option, func() (int, error) {
+		res := 0
+		err := option.Get().Scan(&res)
+		return res, err
+	})
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
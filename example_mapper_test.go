@@ -0,0 +1,106 @@
+package vulcan_test
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mangohow/vulcan"
+	"github.com/mangohow/vulcan/internal/example/db/mapper"
+	"github.com/mangohow/vulcan/internal/example/model"
+)
+
+// 使用测试驱动执行example中生成的代码, 生成的代码需要能够编译并按照预期执行sql
+func TestGeneratedMapper(t *testing.T) {
+	db, state := vulcan.OpenFakeDB(t)
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var queryArgs [][]driver.Value
+	state.SetRowsFunc(func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		queryArgs = append(queryArgs, args)
+		columns := []string{"id", "username", "password", "created_at", "email", "address"}
+		return columns, [][]driver.Value{
+			{int64(1), "mango", "secret", createdAt, "mango@example.com", "shanghai"},
+			{int64(2), "vulcan", "secret", createdAt, "vulcan@example.com", "beijing"},
+		}
+	})
+	repo := mapper.NewUserRepo(db, nil)
+
+	user := &model.User{Username: "mango", Password: "secret", Email: "mango@example.com"}
+	if err := repo.Add(user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Id != 1 {
+		t.Errorf("expected generated id 1, got %d", user.Id)
+	}
+
+	found, err := repo.FindById(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedUser := &model.User{Id: 1, Username: "mango", Password: "secret", CreatedAt: createdAt, Email: "mango@example.com", Address: "shanghai"}
+	if !reflect.DeepEqual(found, expectedUser) {
+		t.Errorf("expected %+v, got %+v", expectedUser, found)
+	}
+
+	users, err := repo.SelectBatchIds([]int{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[1].Username != "vulcan" {
+		t.Errorf("unexpected users %+v", users)
+	}
+
+	if _, err := repo.Find(&model.User{Address: "shanghai"}); err != nil {
+		t.Fatal(err)
+	}
+
+	affected, err := repo.UpdateById(&model.User{Id: 1, Email: "new@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected != 1 {
+		t.Errorf("expected 1 row affected, got %d", affected)
+	}
+
+	expected := []string{
+		"INSERT INTO t_user (id, username, password, created_at, email, address) VALUES (?, ?, ?, ?, ?, ?)",
+		"SELECT id, username, password, created_at, email, address FROM t_user WHERE id = ?",
+		"SELECT id, username, password, created_at, email, address FROM t_user WHERE id IN (?, ?) ",
+		"SELECT id, username, password, created_at, email, address FROM t_user WHERE 1 = 1 AND address = ? ",
+		"UPDATE t_user SET email = ? WHERE id = ? ",
+	}
+	if got := vulcan.Statements(state.Logs()); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	if expectedArgs := [][]driver.Value{{int64(1)}, {int64(1), int64(2)}, {"shanghai"}}; !reflect.DeepEqual(queryArgs, expectedArgs) {
+		t.Errorf("expected query args %v, got %v", expectedArgs, queryArgs)
+	}
+}
+
+func TestGeneratedMapperApplyOptions(t *testing.T) {
+	db, state := vulcan.OpenFakeDB(t)
+	repo := mapper.NewUserRepo(db, nil)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var intercepted *vulcan.ExecOption
+	_, err = repo.DeleteById(1, vulcan.WithTransaction(tx), vulcan.WithInterceptors(func(option *vulcan.ExecOption, next vulcan.Handler) (any, error) {
+		intercepted = option
+		return next(option)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Rollback()
+
+	if intercepted == nil || intercepted.Execer != vulcan.Execer(tx) {
+		t.Fatalf("expected interceptor to see tx execer, got %+v", intercepted)
+	}
+	expected := []string{"BEGIN", "DELETE FROM t_user WHERE id = ?", "ROLLBACK"}
+	if got := state.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
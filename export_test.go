@@ -0,0 +1,23 @@
+package vulcan
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+// 导出测试驱动, 供vulcan_test包中执行生成代码的测试使用
+
+type FakeDB = fakeDB
+
+func OpenFakeDB(t testing.TB) (*sql.DB, *FakeDB) {
+	return openFakeDB(t)
+}
+
+func (f *fakeDB) SetRowsFunc(fn func(query string, args []driver.Value) ([]string, [][]driver.Value)) {
+	f.rowsFunc = fn
+}
+
+func Statements(logs []string) []string {
+	return statements(logs)
+}
@@ -0,0 +1,272 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试使用的数据库驱动, 记录执行过的语句, 不会真正执行sql
+const fakeDriverName = "vulcan-fake"
+
+var (
+	fakeDBs   sync.Map
+	fakeDBSeq int64
+)
+
+func init() {
+	sql.Register(fakeDriverName, fakeDriver{})
+}
+
+type fakeDB struct {
+	mu   sync.Mutex
+	logs []string
+
+	// 可选, 返回执行语句时的错误
+	errFunc func(query string) error
+	// 可选, 返回查询结果
+	rowsFunc func(query string, args []driver.Value) ([]string, [][]driver.Value)
+	// 可选, 返回影响的行数
+	affectedFunc func(query string) int64
+	// 模拟mysql驱动, 带参数的语句需要先预编译再执行
+	prepareOnly bool
+	// 模拟数据库预编译语句的耗时
+	prepareCost time.Duration
+}
+
+func (f *fakeDB) record(s string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logs = append(f.logs, s)
+}
+
+func (f *fakeDB) Logs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.logs...)
+}
+
+func (f *fakeDB) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logs = nil
+}
+
+func openFakeDB(t testing.TB) (*sql.DB, *fakeDB) {
+	t.Helper()
+	dsn := fmt.Sprintf("fake-%d", atomic.AddInt64(&fakeDBSeq, 1))
+	state := &fakeDB{}
+	fakeDBs.Store(dsn, state)
+	db, err := sql.Open(fakeDriverName, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		fakeDBs.Delete(dsn)
+	})
+
+	return db, state
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	v, ok := fakeDBs.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("fake db %s not found", name)
+	}
+
+	return &fakeConn{db: v.(*fakeDB)}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.db.record("PREPARE " + query)
+	if c.db.prepareCost > 0 {
+		time.Sleep(c.db.prepareCost)
+	}
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	s := "BEGIN"
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		s += " " + sql.IsolationLevel(opts.Isolation).String()
+	}
+	if opts.ReadOnly {
+		s += " READ ONLY"
+	}
+	c.db.record(s)
+	return &fakeTx{conn: c}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.db.prepareOnly && len(args) > 0 {
+		return nil, driver.ErrSkip
+	}
+
+	return c.execContext(ctx, query, args)
+}
+
+func (c *fakeConn) execContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.exec(ctx, query); err != nil {
+		return nil, err
+	}
+
+	var affected int64 = 1
+	if c.db.affectedFunc != nil {
+		affected = c.db.affectedFunc(query)
+	}
+
+	return fakeResult{affected: affected}, nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.db.prepareOnly && len(args) > 0 {
+		return nil, driver.ErrSkip
+	}
+
+	return c.queryContext(ctx, query, args)
+}
+
+func (c *fakeConn) queryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.exec(ctx, query); err != nil {
+		return nil, err
+	}
+
+	rows := &fakeRows{}
+	if c.db.rowsFunc != nil {
+		values := make([]driver.Value, len(args))
+		for i := range args {
+			values[i] = args[i].Value
+		}
+		rows.columns, rows.values = c.db.rowsFunc(query, values)
+	}
+
+	return rows, nil
+}
+
+func (c *fakeConn) exec(ctx context.Context, query string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.db.record(query)
+	if c.db.errFunc != nil {
+		return c.db.errFunc(query)
+	}
+
+	return nil
+}
+
+type fakeTx struct {
+	conn *fakeConn
+}
+
+func (t *fakeTx) Commit() error {
+	t.conn.db.record("COMMIT")
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	t.conn.db.record("ROLLBACK")
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	s.conn.db.record("CLOSE " + s.query)
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.execContext(context.Background(), s.query, toNamedValues(args))
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.queryContext(context.Background(), s.query, toNamedValues(args))
+}
+
+func toNamedValues(args []driver.Value) []driver.NamedValue {
+	res := make([]driver.NamedValue, len(args))
+	for i := range args {
+		res[i] = driver.NamedValue{Ordinal: i + 1, Value: args[i]}
+	}
+
+	return res
+}
+
+type fakeResult struct {
+	affected int64
+}
+
+func (r fakeResult) LastInsertId() (int64, error) {
+	return 1, nil
+}
+
+func (r fakeResult) RowsAffected() (int64, error) {
+	return r.affected, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+	idx     int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.idx])
+	r.idx++
+
+	return nil
+}
+
+// 过滤掉PREPARE和CLOSE记录, 方便对比执行的语句
+func statements(logs []string) []string {
+	res := make([]string, 0, len(logs))
+	for _, l := range logs {
+		if strings.HasPrefix(l, "PREPARE ") || strings.HasPrefix(l, "CLOSE ") {
+			continue
+		}
+		res = append(res, l)
+	}
+
+	return res
+}
@@ -3,9 +3,14 @@ module github.com/mangohow/vulcan
 go 1.18
 
 require (
+	github.com/blastrain/vitess-sqlparser v0.0.0-20201030050434-a139afbb1aba
 	github.com/go-sql-driver/mysql v1.8.1
 	github.com/jmoiron/sqlx v1.4.0
 	golang.org/x/sync v0.11.0
 )
 
-require filippo.io/edwards25519 v1.1.0 // indirect
+require (
+	filippo.io/edwards25519 v1.1.0 // indirect
+	github.com/juju/errors v0.0.0-20170703010042-c7d06af17c68 // indirect
+	golang.org/x/text v0.0.0-20180302201248-b7ef84aaf62a // indirect
+)
@@ -3,145 +3,138 @@ package vulcan
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 type Handler func(option *ExecOption) (any, error)
 type InterceptorHandler func(option *ExecOption, next Handler) (any, error)
 
-var (
-	executeInterceptors         []InterceptorHandler
-	sqlDebugInterceptor         InterceptorHandler
-	paginationInterceptor       InterceptorHandler
-	slowQueryLoggingInterceptor InterceptorHandler
-)
-
 func Invoke[T any](option *ExecOption, execHandler func() (T, error)) (T, error) {
 	if option.Ctx == nil {
 		option.Ctx = context.Background()
 	}
-	// 构建拦截器链
-	interceptorChain := buildInterceptorChain(option)
+	option.resolveSensitiveArgs()
+	if err := option.resolveExecer(); err != nil {
+		return *new(T), err
+	}
+	option.resolveDialect()
+	option.withStatementKind()
+	cancel := option.withTxDeadline()
+	defer cancel()
+	cancelTimeout := option.withStatementTimeout()
+	defer cancelTimeout()
+	// 获取拦截器链
+	interceptorChain := option.interceptorChain()
 	if interceptorChain == nil {
-		return execHandler()
+		res, err := execHandler()
+		if err != nil {
+			return res, option.wrapError(err)
+		}
+		return res, nil
 	}
 
-	// 创建最终的执行处理器
-	finalHandler := func(option *ExecOption) (any, error) {
+	// 设置最终的执行处理器, 由拦截器链的最后一个拦截器调用
+	option.execHandler = func(option *ExecOption) (any, error) {
 		return execHandler()
 	}
 
 	// 执行拦截器链
-	res, err := interceptorChain(option, finalHandler)
+	res, err := interceptorChain(option)
 	if err != nil {
-		return *new(T), err
+		return *new(T), option.wrapError(err)
 	}
 
-	return res.(T), nil
-}
-
-// buildInterceptorChain 构建拦截器链
-func buildInterceptorChain(option *ExecOption) InterceptorHandler {
-	// 从上下文中获取额外的拦截器
-	var extraInterceptors []InterceptorHandler
-	value := option.Ctx.Value(interceptorKey{})
-	if value != nil {
-		if handler, ok := value.(InterceptorHandler); ok {
-			extraInterceptors = append(extraInterceptors, handler)
-		} else if handlers, ok := value.([]InterceptorHandler); ok {
-			extraInterceptors = handlers
-		}
+	// 拦截器可能返回nil
+	if res == nil {
+		return *new(T), nil
 	}
-
-	// 获取按正确顺序排列的全局拦截器
-	interceptors := make([]InterceptorHandler, 0, len(executeInterceptors)+len(extraInterceptors)+4)
-
-	// 获取缓存interceptor
-	if cacheInterceptor := getCacheInterceptor(option.Ctx); cacheInterceptor != nil {
-		interceptors = append(interceptors, cacheInterceptor)
+	v, ok := res.(T)
+	if !ok {
+		return v, option.wrapError(fmt.Errorf("interceptor returned %T, expected %s", res, reflect.TypeOf((*T)(nil)).Elem()))
 	}
+	return v, nil
+}
 
-	// 1. 分页拦截器优先执行
-	if paginationInterceptor != nil {
-		interceptors = append(interceptors, paginationInterceptor)
-	}
+type DebugLogger interface {
+	Debug(format string, args ...any)
+}
 
-	// 2. SQL调试拦截器
-	if sqlDebugInterceptor != nil {
-		interceptors = append(interceptors, sqlDebugInterceptor)
-	}
+// SqlDebugOptions sql调试日志选项
+type SqlDebugOptions struct {
+	// Render 将参数按照方言替换到sql中, 输出一条可以直接执行的sql语句
+	Render bool
+}
 
-	// 3. 自定义拦截器
-	interceptors = append(interceptors, executeInterceptors...)
-	interceptors = append(interceptors, extraInterceptors...)
+func SetupSqlDebugInterceptor(logger DebugLogger) {
+	RegisterInterceptor(InterceptorSqlDebug, OrderSqlDebug, SqlDebugInterceptor(logger, nil))
+}
 
-	// 4. 慢查询日志拦截器最后执行
-	if slowQueryLoggingInterceptor != nil {
-		interceptors = append(interceptors, slowQueryLoggingInterceptor)
+// SqlDebugInterceptor 创建sql调试日志拦截器, 执行结束后输出耗时以及返回或者影响的行数
+func SqlDebugInterceptor(logger DebugLogger, options *SqlDebugOptions) InterceptorHandler {
+	if options == nil {
+		options = &SqlDebugOptions{}
 	}
 
-	// 如果没有拦截器，直接返回nil
-	if len(interceptors) == 0 {
-		return nil
-	}
+	return func(option *ExecOption, next Handler) (any, error) {
+		if options.Render {
+			logger.Debug("SQL        ==> %s", RenderSQL(option.Dialect, option.SqlStmt, option.MaskedArgs()))
+		} else {
+			logger.Debug("SQL        ==> %s", option.SqlStmt)
+			logger.Debug("PARAMETERS ==> %s", formatArgs(option.MaskedArgs()))
+		}
 
-	// 返回链式调用的拦截器
-	return chainInterceptors(interceptors)
-}
+		start := time.Now()
+		res, err := next(option)
+		elapsed := time.Since(start)
+		if err != nil {
+			logger.Debug("ERROR      <== %v, elapsed: %s", err, elapsed)
+		} else if rows, ok := resultRows(res); ok {
+			logger.Debug("ROWS       <== %d, elapsed: %s", rows, elapsed)
+		} else {
+			logger.Debug("ELAPSED    <== %s", elapsed)
+		}
 
-// chainInterceptors 将多个拦截器链接成一个
-func chainInterceptors(interceptors []InterceptorHandler) InterceptorHandler {
-	return func(option *ExecOption, finalHandler Handler) (any, error) {
-		return interceptors[0](option, getChainHandler(interceptors, 0, finalHandler))
+		return res, err
 	}
 }
 
-// getChainHandler 获取下一个拦截器
-func getChainHandler(interceptors []InterceptorHandler, curr int, finalHandler Handler) Handler {
-	if curr == len(interceptors)-1 {
-		return finalHandler
+func formatArgs(args []any) string {
+	builder := strings.Builder{}
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			builder.WriteString(fmt.Sprintf("%T(%q)", v, v))
+		case time.Time:
+			builder.WriteString(fmt.Sprintf("DATETIME(%s)", v.Format(time.DateTime)))
+		case *time.Time:
+			if v != nil {
+				builder.WriteString(fmt.Sprintf("DATETIME(%s)", v.Format(time.DateTime)))
+			} else {
+				builder.WriteString("DATETIME(NULL)")
+			}
+		default:
+			builder.WriteString(fmt.Sprintf("%T(%v)", arg, arg))
+		}
+		if i != len(args)-1 {
+			builder.WriteString(", ")
+		}
 	}
 
-	return func(option *ExecOption) (any, error) {
-		return interceptors[curr+1](option, getChainHandler(interceptors, curr+1, finalHandler))
-	}
+	return builder.String()
 }
 
-type DebugLogger interface {
-	Debug(format string, args ...any)
-}
-
-func SetupSqlDebugInterceptor(logger DebugLogger) {
-	sqlDebugInterceptor = func(option *ExecOption, next Handler) (any, error) {
-		logger.Debug("SQL        ==> %s", option.SqlStmt)
-		builder := strings.Builder{}
-		for i, arg := range option.Args {
-			switch arg.(type) {
-			case string:
-				builder.WriteString(fmt.Sprintf("%T(%q)", arg, arg))
-			case time.Time:
-				builder.WriteString(fmt.Sprintf("DATETIME(%s)", arg.(time.Time).Format(time.DateTime)))
-			case *time.Time:
-				if arg != nil {
-					builder.WriteString(fmt.Sprintf("DATETIME(%v)", arg.(*time.Time).Format(time.DateTime)))
-				}
-			default:
-				builder.WriteString(fmt.Sprintf("%T(%v)", arg, arg))
-			}
-			if i != len(option.Args)-1 {
-				builder.WriteString(", ")
-			}
-		}
-		logger.Debug("PARAMETERS ==> " + builder.String())
-
-		return next(option)
-	}
+// 判断是否为SELECT语句, 拦截器改写后的sql为小写, 不区分大小写
+func isSelectStmt(query string) bool {
+	query = strings.TrimSpace(query)
+	return len(query) >= 6 && strings.EqualFold(query[:6], "SELECT")
 }
 
 func SetupPaginationInterceptor() {
-	paginationInterceptor = func(option *ExecOption, next Handler) (any, error) {
-		if option.Extension == nil || !strings.HasPrefix(option.SqlStmt, "SELECT") {
+	RegisterInterceptor(InterceptorPagination, OrderPagination, func(option *ExecOption, next Handler) (any, error) {
+		if option.Extension == nil || !isSelectStmt(option.SqlStmt) {
 			return next(option)
 		}
 
@@ -150,68 +143,82 @@ func SetupPaginationInterceptor() {
 			return next(option)
 		}
 
-		tail := fmt.Sprintf("LIMIT %d, %d", page.PageSize(), (page.PageNum()-1)*page.PageSize())
-		if len(page.Orders()) != 0 {
-			tail = page.Orders().SqlStmt() + tail
+		dialect := option.Dialect
+		if dialect == nil {
+			dialect = DefaultDialect
 		}
 
-		option.SqlStmt += tail
-		if !page.IsSelectCount() {
-			return next(option)
+		// count语句使用没有排序和分页的sql
+		if page.IsSelectCount() {
+			countOption := &ExecOption{
+				SqlStmt: page.GetSelectCountSql(option.SqlStmt),
+				Args:    option.Args,
+				Execer:  option.Execer,
+				Ctx:     option.Ctx,
+				Dialect: dialect,
+				Meta:    option.Meta,
+
+				sensitiveArgs: option.sensitiveArgs,
+			}
+			selectCount := func(option *ExecOption) (any, error) {
+				var count int
+				err := option.Get().Scan(&count)
+				return count, err
+			}
+			if debug := option.lookupInterceptor(InterceptorSqlDebug); debug != nil {
+				selectCount = chainHandler(debug, selectCount)
+			}
+			res, err := selectCount(countOption)
+			if err != nil {
+				return nil, err
+			}
+			count := res.(int)
+			page.SetTotalCount(count)
+			totalPage := count / page.PageSize()
+			if count%page.PageSize() != 0 {
+				totalPage++
+			}
+			page.SetTotalPages(totalPage)
 		}
 
-		countSql := page.GetSelectCountSql(option.SqlStmt)
-
-		if sqlDebugInterceptor != nil {
-			sqlDebugInterceptor(&ExecOption{SqlStmt: countSql}, func(option *ExecOption) (any, error) {
-				return nil, nil
-			})
-		}
-		var count int
-		option.Execer.Exec(countSql, &count)
-		page.SetTotalCount(count)
-		totalPage := count / page.PageSize()
-		if count%page.PageSize() != 0 {
-			totalPage++
+		tail := " " + dialect.LimitOffset(page.PageSize(), (page.PageNum()-1)*page.PageSize())
+		if len(page.Orders()) != 0 {
+			tail = page.Orders().SqlStmt() + tail
 		}
-		page.SetTotalPages(totalPage)
+		option.SqlStmt += tail
+		// 分页参数不同时sql也不同, 不使用预编译语句缓存
+		option.Static = false
 
 		return next(option)
-	}
+	})
 }
 
-type slowQueryKey struct{}
-type slowQuerySqlKey struct{}
-
+// SetupSlowQueryLoggingInterceptor 注册慢查询拦截器, limit单位为毫秒
+// Deprecated: 使用SetupSlowQueryInterceptor, 可以记录参数、错误以及执行计划
 func SetupSlowQueryLoggingInterceptor(limit int64, loggerFunc func(used int64, sql string)) {
-	slowQueryLoggingInterceptor = func(option *ExecOption, next Handler) (any, error) {
-		start := time.Now().UnixMilli()
-		resp, err := next(option)
-		if err != nil {
-			return resp, err
-		}
-
-		used := time.Now().UnixMilli() - start
-		if used > limit {
-			loggerFunc(used, option.SqlStmt)
-		}
-
-		return resp, nil
-	}
+	SetupSlowQueryInterceptor(SlowQueryOptions{
+		Threshold: time.Duration(limit) * time.Millisecond,
+		Logger: func(query *SlowQuery) {
+			loggerFunc(query.Duration.Milliseconds(), query.SQL)
+		},
+	})
 }
 
 func SetPaginationInterceptor(interceptor InterceptorHandler) {
-	paginationInterceptor = interceptor
+	RegisterInterceptor(InterceptorPagination, OrderPagination, interceptor)
 }
 
 func SetSqlDebugInterceptor(interceptor InterceptorHandler) {
-	sqlDebugInterceptor = interceptor
+	RegisterInterceptor(InterceptorSqlDebug, OrderSqlDebug, interceptor)
 }
 
 func SetSlowQueryLoggingInterceptor(interceptor InterceptorHandler) {
-	slowQueryLoggingInterceptor = interceptor
+	RegisterInterceptor(InterceptorSlowQuery, OrderSlowQuery, interceptor)
 }
 
+// AddInterceptors 添加自定义拦截器, 按照添加顺序在OrderDefault执行
 func AddInterceptors(interceptors ...InterceptorHandler) {
-	executeInterceptors = append(executeInterceptors, interceptors...)
+	for _, interceptor := range interceptors {
+		RegisterInterceptor(fmt.Sprintf("interceptor-%d", atomic.AddUint64(&customInterceptorSeq, 1)), OrderDefault, interceptor)
+	}
 }
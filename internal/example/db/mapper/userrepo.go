@@ -24,13 +24,13 @@ func NewUserRepo(db *sql.DB, cacheManager vulcan.CacheManger[model.User]) *UserR
 }
 
 func (m *UserRepo) Add(user *model.User) {
-	Insert(`INSERT INTO t_user (id, username, password, create_at, email, address) 
-            VALUES (#{user.Id}, #{user.Username}, #{user.Password}, #{user.CreateAt}, #{user.Email}, #{user.Address})`)
+	Insert(`INSERT INTO t_user (id, username, password, created_at, email, address) 
+            VALUES (#{user.Id}, #{user.Username}, #{user.Password}, #{user.CreatedAt}, #{user.Email}, #{user.Address})`)
 }
 
 func (m *UserRepo) Add1(user *model.User) int {
-	Insert(`INSERT INTO t_user (id, username, password, create_at, email, address) 
-            VALUES (#{user.Id}, #{user.Username}, #{user.Password}, #{user.CreateAt}, #{user.Email}, #{user.Address})`)
+	Insert(`INSERT INTO t_user (id, username, password, created_at, email, address) 
+            VALUES (#{user.Id}, #{user.Username}, #{user.Password}, #{user.CreatedAt}, #{user.Email}, #{user.Address})`)
 }
 
 func (m *UserRepo) DeleteById(id int) int {
@@ -55,8 +55,8 @@ func (m *UserRepo) UpdateById(user *model.User) int {
 func (m *UserRepo) Find(user *model.User) *model.User {
 	Select(SQL().
 		Stmt("SELECT * FROM t_user").
-		Where(If(user.Username != "", "username = #{user.Username}").
-			If(user.Address != "", "address = #{user.Address}")).
+		Where(If(user.Username != "", "AND username = #{user.Username}").
+			If(user.Address != "", "AND address = #{user.Address}")).
 		Build())
 	return nil
 }
@@ -64,17 +64,17 @@ func (m *UserRepo) Find(user *model.User) *model.User {
 func (m *UserRepo) Find2(user *model.User) model.User {
 	Select(SQL().
 		Stmt("SELECT * FROM t_user").
-		Where(If(user.Username != "", "username = #{user.Username}").
-			If(user.Address != "", "address = #{user.Address}")).
+		Where(If(user.Username != "", "AND username = #{user.Username}").
+			If(user.Address != "", "AND address = #{user.Address}")).
 		Build())
 	return nil
 }
 
-func (m *UserRepo) BatchAdd(users []*model.User) {
+func (m *UserRepo) BatchAdd(users []*model.User) int {
 	Insert(SQL().
-		Stmt("INSERT INTO t_user (id, username, password, create_at, email, address) VALUES ").
+		Stmt("INSERT INTO t_user (id, username, password, created_at, email, address) VALUES ").
 		Foreach("users", "user", ", ", "", "",
-			"(#{user.Id}, #{user.Username}, #{user.Password}, #{user.CreateAt}, #{user.Email}, #{user.Address})").Build())
+			"(#{user.Id}, #{user.Username}, #{user.Password}, #{user.CreatedAt}, #{user.Email}, #{user.Address})").Build())
 }
 
 func (m *UserRepo) UpdateByIdOrUsername(user *model.User) {
@@ -82,8 +82,8 @@ func (m *UserRepo) UpdateByIdOrUsername(user *model.User) {
 		Stmt("UPDATE t_user").
 		Set(If(user.Password != "", "password = #{user.Password}").
 			If(user.Email != "", "email = #{user.Email}")).
-		Where(Choose().When(user.Id > 0, "id = #{user.Id}").
-			When(user.Username != "", "username = #{user.Username}")).
+		Where(Choose().When(user.Id > 0, "AND id = #{user.Id}").
+			When(user.Username != "", "AND username = #{user.Username}")).
 		Build())
 }
 
@@ -1,13 +1,12 @@
 // Code generated by vulcan. DO NOT EDIT.
 // version: vulcan v1.0
+
 package mapper
 
 import (
 	"database/sql"
-	"fmt"
 	"github.com/mangohow/vulcan"
 	"github.com/mangohow/vulcan/internal/example/model"
-	"time"
 )
 
 type UserRepo struct {
@@ -16,18 +15,30 @@ type UserRepo struct {
 }
 
 func NewUserRepo(db *sql.DB, cacheManager vulcan.CacheManger[model.User]) *UserRepo {
-	return &UserRepo{
-		db:           db,
-		cacheManager: cacheManager,
-	}
+	return &UserRepo{db: db, cacheManager: cacheManager}
 }
 
 func (m *UserRepo) Add(user *model.User, opts ...vulcan.Option) error {
+	if err := vulcan.FillMeta(nil, opts, vulcan.FillInsert, []vulcan.FillField{
+		{Column: "created_at", Value: &user.CreatedAt},
+	}); err != nil {
+		return err
+	}
 	option := &vulcan.ExecOption{
 		SqlStmt: "INSERT INTO t_user (id, username, password, created_at, email, address) VALUES (?, ?, ?, ?, ?, ?)",
 		Args:    []any{user.Id, user.Username, user.Password, user.CreatedAt, user.Email, user.Address},
 		Execer:  m.db,
-	}
+		Static:  true,
+		Meta: &vulcan.StatementMeta{
+			Mapper:    "UserRepo",
+			Method:    "Add",
+			Kind:      vulcan.SqlTypeInsert,
+			Table:     "t_user",
+			Source:    "userrepo.go:26",
+			Sensitive: []int{2, 4},
+		},
+	}
+	option.Apply(opts...)
 	result, err := vulcan.Invoke(option, func() (sql.Result, error) {
 		return option.Exec()
 	})
@@ -35,22 +46,37 @@ func (m *UserRepo) Add(user *model.User, opts ...vulcan.Option) error {
 		return err
 	}
 
-	id, err := result.LastInsertId()
+	lasInsertedId, err := result.LastInsertId()
 	if err != nil {
 		return err
 	}
-	user.Id = id
+
+	user.Id = lasInsertedId
 
 	return nil
 }
 
 func (m *UserRepo) Add1(user *model.User, opts ...vulcan.Option) (int, error) {
+	if err := vulcan.FillMeta(nil, opts, vulcan.FillInsert, []vulcan.FillField{
+		{Column: "created_at", Value: &user.CreatedAt},
+	}); err != nil {
+		return 0, err
+	}
 	option := &vulcan.ExecOption{
 		SqlStmt: "INSERT INTO t_user (id, username, password, created_at, email, address) VALUES (?, ?, ?, ?, ?, ?)",
 		Args:    []any{user.Id, user.Username, user.Password, user.CreatedAt, user.Email, user.Address},
 		Execer:  m.db,
-	}
-
+		Static:  true,
+		Meta: &vulcan.StatementMeta{
+			Mapper:    "UserRepo",
+			Method:    "Add1",
+			Kind:      vulcan.SqlTypeInsert,
+			Table:     "t_user",
+			Source:    "userrepo.go:31",
+			Sensitive: []int{2, 4},
+		},
+	}
+	option.Apply(opts...)
 	result, err := vulcan.Invoke(option, func() (sql.Result, error) {
 		return option.Exec()
 	})
@@ -58,18 +84,19 @@ func (m *UserRepo) Add1(user *model.User, opts ...vulcan.Option) (int, error) {
 		return 0, err
 	}
 
-	id, err := result.LastInsertId()
+	lasInsertedId, err := result.LastInsertId()
 	if err != nil {
 		return 0, err
 	}
-	user.Id = id
 
-	affected, err := result.RowsAffected()
+	user.Id = lasInsertedId
+
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
 
-	return int(affected), nil
+	return int(rowsAffected), nil
 }
 
 func (m *UserRepo) DeleteById(id int, opts ...vulcan.Option) (int, error) {
@@ -77,7 +104,16 @@ func (m *UserRepo) DeleteById(id int, opts ...vulcan.Option) (int, error) {
 		SqlStmt: "DELETE FROM t_user WHERE id = ?",
 		Args:    []any{id},
 		Execer:  m.db,
-	}
+		Static:  true,
+		Meta: &vulcan.StatementMeta{
+			Mapper: "UserRepo",
+			Method: "DeleteById",
+			Kind:   vulcan.SqlTypeDelete,
+			Table:  "t_user",
+			Source: "userrepo.go:36",
+		},
+	}
+	option.Apply(opts...)
 	result, err := vulcan.Invoke(option, func() (sql.Result, error) {
 		return option.Exec()
 	})
@@ -85,12 +121,12 @@ func (m *UserRepo) DeleteById(id int, opts ...vulcan.Option) (int, error) {
 		return 0, err
 	}
 
-	affected, err := result.RowsAffected()
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
 
-	return int(affected), nil
+	return int(rowsAffected), nil
 }
 
 func (m *UserRepo) FindById(id int, opts ...vulcan.Option) (*model.User, error) {
@@ -98,13 +134,21 @@ func (m *UserRepo) FindById(id int, opts ...vulcan.Option) (*model.User, error)
 		SqlStmt: "SELECT id, username, password, created_at, email, address FROM t_user WHERE id = ?",
 		Args:    []any{id},
 		Execer:  m.db,
-	}
+		Static:  true,
+		Meta: &vulcan.StatementMeta{
+			Mapper: "UserRepo",
+			Method: "FindById",
+			Kind:   vulcan.SqlTypeSelect,
+			Table:  "t_user",
+			Source: "userrepo.go:41",
+		},
+	}
+	option.Apply(opts...)
 	result, err := vulcan.Invoke(option, func() (*model.User, error) {
 		res := &model.User{}
 		err := option.Get().Scan(&res.Id, &res.Username, &res.Password, &res.CreatedAt, &res.Email, &res.Address)
 		return res, err
 	})
-
 	if err != nil {
 		return nil, err
 	}
@@ -113,20 +157,26 @@ func (m *UserRepo) FindById(id int, opts ...vulcan.Option) (*model.User, error)
 }
 
 func (m *UserRepo) UpdateById(user *model.User, opts ...vulcan.Option) (int, error) {
-	builder := vulcan.NewSqlBuilder(128, 0, 3)
+	builder := vulcan.NewSqlBuilder(64, 0, 3)
 	builder.AppendStmt("UPDATE t_user ")
-	builder.AppendSetStmtConditional(user.Password != "", "password = ?", user.Password).
-		AppendSetStmtConditional(user.Email != "", "email = ?", user.Email).
-		AppendSetStmtConditional(user.Address != "", "address = ?", user.Address).
-		EndSetStmt()
-	builder.AppendStmt("WHERE id = ?", user.Id)
-
+	builder.AppendSetStmtConditional(user.Password != "", "password = ?", vulcan.Sensitive(user.Password)).
+		AppendSetStmtConditional(user.Email != "", "email = ?", vulcan.Sensitive(user.Email)).
+		AppendSetStmtConditional(user.Address != "", "address = ?", user.Address).EndSetStmt()
+	builder.AppendStmt("WHERE id = ? ", user.Id)
 	option := &vulcan.ExecOption{
 		SqlStmt: builder.String(),
 		Args:    builder.Args(),
 		Execer:  m.db,
-	}
-
+		Meta: &vulcan.StatementMeta{
+			Mapper:  "UserRepo",
+			Method:  "UpdateById",
+			Kind:    vulcan.SqlTypeUpdate,
+			Table:   "t_user",
+			Source:  "userrepo.go:46",
+			Dynamic: true,
+		},
+	}
+	option.Apply(opts...)
 	result, err := vulcan.Invoke(option, func() (sql.Result, error) {
 		return option.Exec()
 	})
@@ -134,27 +184,33 @@ func (m *UserRepo) UpdateById(user *model.User, opts ...vulcan.Option) (int, err
 		return 0, err
 	}
 
-	affected, err := result.RowsAffected()
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
 
-	return int(affected), nil
+	return int(rowsAffected), nil
 }
 
-func (m *UserRepo) Find(user *model.User) (*model.User, error) {
+func (m *UserRepo) Find(user *model.User, opts ...vulcan.Option) (*model.User, error) {
 	builder := vulcan.NewSqlBuilder(128, 2, 0)
 	builder.AppendStmt("SELECT id, username, password, created_at, email, address FROM t_user ")
 	builder.AppendWhereStmtConditional(user.Username != "", "AND username = ?", user.Username).
-		AppendWhereStmtConditional(user.Password != "", "AND password = ?", user.Password).
-		EndWhereStmt()
-
+		AppendWhereStmtConditional(user.Address != "", "AND address = ?", user.Address).EndWhereStmt()
 	option := &vulcan.ExecOption{
 		SqlStmt: builder.String(),
 		Args:    builder.Args(),
 		Execer:  m.db,
-	}
-
+		Meta: &vulcan.StatementMeta{
+			Mapper:  "UserRepo",
+			Method:  "Find",
+			Kind:    vulcan.SqlTypeSelect,
+			Table:   "t_user",
+			Source:  "userrepo.go:55",
+			Dynamic: true,
+		},
+	}
+	option.Apply(opts...)
 	result, err := vulcan.Invoke(option, func() (*model.User, error) {
 		res := &model.User{}
 		err := option.Get().Scan(&res.Id, &res.Username, &res.Password, &res.CreatedAt, &res.Email, &res.Address)
@@ -167,44 +223,64 @@ func (m *UserRepo) Find(user *model.User) (*model.User, error) {
 	return result, nil
 }
 
-func (m *UserRepo) Find2(user *model.User) (model.User, error) {
+func (m *UserRepo) Find2(user *model.User, opts ...vulcan.Option) (model.User, error) {
 	builder := vulcan.NewSqlBuilder(128, 2, 0)
 	builder.AppendStmt("SELECT id, username, password, created_at, email, address FROM t_user ")
 	builder.AppendWhereStmtConditional(user.Username != "", "AND username = ?", user.Username).
-		AppendWhereStmtConditional(user.Password != "", "AND password = ?", user.Password).
-		EndWhereStmt()
-
+		AppendWhereStmtConditional(user.Address != "", "AND address = ?", user.Address).EndWhereStmt()
 	option := &vulcan.ExecOption{
 		SqlStmt: builder.String(),
 		Args:    builder.Args(),
 		Execer:  m.db,
-	}
+		Meta: &vulcan.StatementMeta{
+			Mapper:  "UserRepo",
+			Method:  "Find2",
+			Kind:    vulcan.SqlTypeSelect,
+			Table:   "t_user",
+			Source:  "userrepo.go:64",
+			Dynamic: true,
+		},
+	}
+	option.Apply(opts...)
 	result, err := vulcan.Invoke(option, func() (model.User, error) {
 		res := model.User{}
 		err := option.Get().Scan(&res.Id, &res.Username, &res.Password, &res.CreatedAt, &res.Email, &res.Address)
 		return res, err
 	})
-
 	if err != nil {
-		return model.User{}, err
+		return result, err
 	}
 
 	return result, nil
 }
 
 func (m *UserRepo) BatchAdd(users []*model.User, opts ...vulcan.Option) (int, error) {
+	for i := range users {
+		if err := vulcan.FillMeta(nil, opts, vulcan.FillInsert, []vulcan.FillField{
+			{Column: "created_at", Value: &users[i].CreatedAt},
+		}); err != nil {
+			return 0, err
+		}
+	}
 	builder := vulcan.NewSqlBuilder(128, 0, 0)
 	builder.AppendStmt("INSERT INTO t_user (id, username, password, created_at, email, address) VALUES ")
 	vulcan.AppendLoopStmt(builder, users, ", ", "", "", func(user *model.User) []any {
-		return []any{user.Id, user.Username, user.Password, user.CreatedAt, user.Email, user.Address}
+		return []any{user.Id, user.Username, vulcan.Sensitive(user.Password), user.CreatedAt, vulcan.Sensitive(user.Email), user.Address}
 	}, "(?, ?, ?, ?, ?, ?)")
-
 	option := &vulcan.ExecOption{
 		SqlStmt: builder.String(),
 		Args:    builder.Args(),
 		Execer:  m.db,
-	}
-
+		Meta: &vulcan.StatementMeta{
+			Mapper:  "UserRepo",
+			Method:  "BatchAdd",
+			Kind:    vulcan.SqlTypeInsert,
+			Table:   "t_user",
+			Source:  "userrepo.go:73",
+			Dynamic: true,
+		},
+	}
+	option.Apply(opts...)
 	result, err := vulcan.Invoke(option, func() (sql.Result, error) {
 		return option.Exec()
 	})
@@ -212,29 +288,36 @@ func (m *UserRepo) BatchAdd(users []*model.User, opts ...vulcan.Option) (int, er
 		return 0, err
 	}
 
-	affected, err := result.RowsAffected()
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
 
-	return int(affected), nil
+	return int(rowsAffected), nil
 }
 
 func (m *UserRepo) UpdateByIdOrUsername(user *model.User, opts ...vulcan.Option) error {
-	builder := vulcan.NewSqlBuilder(128, 1, 2)
-	builder.AppendStmt("UPDATE t_user SET")
-	builder.AppendSetStmtConditional(user.Password != "", "password = ?", user.Password).
-		AppendSetStmtConditional(user.Email != "", "email = ?", user.Email)
+	builder := vulcan.NewSqlBuilder(64, 0, 2)
+	builder.AppendStmt("UPDATE t_user ")
+	builder.AppendSetStmtConditional(user.Password != "", "password = ?", vulcan.Sensitive(user.Password)).
+		AppendSetStmtConditional(user.Email != "", "email = ?", vulcan.Sensitive(user.Email)).EndSetStmt()
 	builder.AppendWhereStmtChoosed(vulcan.MakeSlice(
-		vulcan.NewConditionSql(user.Id > 0, "id = ?", user.Id),
-		vulcan.NewConditionSql(user.Username != "", "username = ?", user.Username)), "", nil)
-
+		vulcan.NewConditionSql(user.Id > 0, "AND id = ?"),
+		vulcan.NewConditionSql(user.Username != "", "AND username = ?")), "", nil)
 	option := &vulcan.ExecOption{
 		SqlStmt: builder.String(),
 		Args:    builder.Args(),
 		Execer:  m.db,
-	}
-
+		Meta: &vulcan.StatementMeta{
+			Mapper:  "UserRepo",
+			Method:  "UpdateByIdOrUsername",
+			Kind:    vulcan.SqlTypeUpdate,
+			Table:   "t_user",
+			Source:  "userrepo.go:80",
+			Dynamic: true,
+		},
+	}
+	option.Apply(opts...)
 	_, err := vulcan.Invoke(option, func() (sql.Result, error) {
 		return option.Exec()
 	})
@@ -245,19 +328,26 @@ func (m *UserRepo) UpdateByIdOrUsername(user *model.User, opts ...vulcan.Option)
 	return nil
 }
 
-func (u *UserRepo) SelectPage(page vulcan.Page, cond *model.QueryCond) ([]*model.User, error) {
+func (u *UserRepo) SelectPage(page vulcan.Page, cond *model.QueryCond, opts ...vulcan.Option) ([]*model.User, error) {
 	builder := vulcan.NewSqlBuilder(128, 2, 0)
 	builder.AppendStmt("SELECT id, username, password, created_at, email, address FROM t_user ")
-	builder.AppendWhereStmtConditional(cond.Username != "", "AND username = ?", cond.Username).
-		AppendWhereStmtConditional(cond.Address != "", "AND address = ?", cond.Address).
-		EndWhereStmt()
-
+	builder.AppendWhereStmtConditional(cond.Username != "", "And username = ?", cond.Username).
+		AppendWhereStmtConditional(cond.Address != "", "AND address = ?", cond.Address).EndWhereStmt()
 	option := &vulcan.ExecOption{
 		SqlStmt:   builder.String(),
 		Args:      builder.Args(),
+		Execer:    u.db,
 		Extension: page,
-	}
-
+		Meta: &vulcan.StatementMeta{
+			Mapper:  "UserRepo",
+			Method:  "SelectPage",
+			Kind:    vulcan.SqlTypeSelect,
+			Table:   "t_user",
+			Source:  "userrepo.go:90",
+			Dynamic: true,
+		},
+	}
+	option.Apply(opts...)
 	result, err := vulcan.Invoke(option, func() ([]*model.User, error) {
 		res := []*model.User{}
 		rows, err := option.Select()
@@ -275,7 +365,6 @@ func (u *UserRepo) SelectPage(page vulcan.Page, cond *model.QueryCond) ([]*model
 		}
 		return res, err
 	})
-
 	if err != nil {
 		return nil, err
 	}
@@ -283,19 +372,26 @@ func (u *UserRepo) SelectPage(page vulcan.Page, cond *model.QueryCond) ([]*model
 	return result, nil
 }
 
-func (u *UserRepo) SelectBatchIds(ids []int) ([]*model.User, error) {
-	builder := vulcan.NewSqlBuilder(128, 2, 0)
+func (u *UserRepo) SelectBatchIds(ids []int, opts ...vulcan.Option) ([]*model.User, error) {
+	builder := vulcan.NewSqlBuilder(128, 0, 0)
 	builder.AppendStmt("SELECT id, username, password, created_at, email, address FROM t_user WHERE id IN ")
 	vulcan.AppendLoopStmt(builder, ids, ", ", "(", ")", func(id int) []any {
 		return []any{id}
 	}, "?")
-
 	option := &vulcan.ExecOption{
 		SqlStmt: builder.String(),
 		Args:    builder.Args(),
 		Execer:  u.db,
-	}
-
+		Meta: &vulcan.StatementMeta{
+			Mapper:  "UserRepo",
+			Method:  "SelectBatchIds",
+			Kind:    vulcan.SqlTypeSelect,
+			Table:   "t_user",
+			Source:  "userrepo.go:98",
+			Dynamic: true,
+		},
+	}
+	option.Apply(opts...)
 	result, err := vulcan.Invoke(option, func() ([]*model.User, error) {
 		res := []*model.User{}
 		rows, err := option.Select()
@@ -313,7 +409,6 @@ func (u *UserRepo) SelectBatchIds(ids []int) ([]*model.User, error) {
 		}
 		return res, err
 	})
-
 	if err != nil {
 		return nil, err
 	}
@@ -326,18 +421,21 @@ func (m *UserRepo) FindByIdCached(id int, opts ...vulcan.Option) (*model.User, e
 		SqlStmt: "SELECT id, username, password, created_at, email, address FROM t_user WHERE id = ?",
 		Args:    []any{id},
 		Execer:  m.db,
-		Ctx: vulcan.CacheableCtx(&vulcan.CacheConfig[model.User]{
-			Manager:      m.cacheManager,
-			Key:          fmt.Sprintf("user:id:%d", id),
-			QueryTimeOut: time.Second * 10,
-		}),
-	}
+		Static:  true,
+		Meta: &vulcan.StatementMeta{
+			Mapper: "UserRepo",
+			Method: "FindByIdCached",
+			Kind:   vulcan.SqlTypeSelect,
+			Table:  "t_user",
+			Source: "userrepo.go:105",
+		},
+	}
+	option.Apply(opts...)
 	result, err := vulcan.Invoke(option, func() (*model.User, error) {
 		res := &model.User{}
 		err := option.Get().Scan(&res.Id, &res.Username, &res.Password, &res.CreatedAt, &res.Email, &res.Address)
 		return res, err
 	})
-
 	if err != nil {
 		return nil, err
 	}
@@ -346,24 +444,26 @@ func (m *UserRepo) FindByIdCached(id int, opts ...vulcan.Option) (*model.User, e
 }
 
 func (m *UserRepo) UpdateByIdEvict(user *model.User, opts ...vulcan.Option) (int, error) {
-	builder := vulcan.NewSqlBuilder(128, 0, 3)
-	builder.AppendStmt("UPDATE t_user SET ")
-	builder.AppendSetStmtConditional(user.Password != "", "password = ?", user.Password).
-		AppendSetStmtConditional(user.Email != "", "email = ?", user.Email).
-		AppendSetStmtConditional(user.Address != "", "address = ?", user.Address).
-		EndSetStmt()
+	builder := vulcan.NewSqlBuilder(64, 0, 3)
+	builder.AppendStmt("UPDATE t_user ")
+	builder.AppendSetStmtConditional(user.Password != "", "password = ?", vulcan.Sensitive(user.Password)).
+		AppendSetStmtConditional(user.Email != "", "email = ?", vulcan.Sensitive(user.Email)).
+		AppendSetStmtConditional(user.Address != "", "address = ?", user.Address).EndSetStmt()
 	builder.AppendStmt("WHERE id = ? ", user.Id)
-
 	option := &vulcan.ExecOption{
 		SqlStmt: builder.String(),
 		Args:    builder.Args(),
 		Execer:  m.db,
-		Ctx: vulcan.CacheEvictCtx(&vulcan.CacheConfig[model.User]{
-			Manager: m.cacheManager,
-			Key:     fmt.Sprintf("user:id:%d", user.Id),
-		}),
-	}
-
+		Meta: &vulcan.StatementMeta{
+			Mapper:  "UserRepo",
+			Method:  "UpdateByIdEvict",
+			Kind:    vulcan.SqlTypeUpdate,
+			Table:   "t_user",
+			Source:  "userrepo.go:111",
+			Dynamic: true,
+		},
+	}
+	option.Apply(opts...)
 	result, err := vulcan.Invoke(option, func() (sql.Result, error) {
 		return option.Exec()
 	})
@@ -371,10 +471,10 @@ func (m *UserRepo) UpdateByIdEvict(user *model.User, opts ...vulcan.Option) (int
 		return 0, err
 	}
 
-	affected, err := result.RowsAffected()
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
 
-	return int(affected), nil
+	return int(rowsAffected), nil
 }
@@ -7,11 +7,11 @@ import (
 )
 
 type User struct {
-	annotation.TableProperty `tableName:"t_user" gen:"UpdateById([3 5 6], true)|SelectOneByUsernameAndPassword([2 3], [], false)"`
+	annotation.TableProperty `tableName:"t_user" sensitive:"email" gen:"UpdateById([3 5 6], true)|SelectOneByUsernameAndPassword([2 3], [], false)"`
 	Id                       int64     `db:"id,pk"`
 	Username                 string    `db:"username"`
-	Password                 string    `db:"password"`
-	CreatedAt                time.Time `db:"created_at"`
+	Password                 string    `db:"password,sensitive"`
+	CreatedAt                time.Time `db:"created_at,fill_insert"`
 	Email                    string    `db:"email"`
 	Address                  string    `db:"address"`
 }
@@ -0,0 +1,80 @@
+package vulcan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blastrain/vitess-sqlparser/sqlparser"
+)
+
+// IncludeDeleted 本次查询不过滤逻辑删除的行
+func IncludeDeleted() Option {
+	return SkipInterceptors(InterceptorLogicDelete)
+}
+
+var logicDeleteRewriteCache rewriteCache
+
+// 逻辑删除拦截器, 默认注册
+// 生成代码时主表使用了逻辑删除的查询语句会设置StatementMeta.LogicDelete, 执行时为sql中的主表添加column IS NULL条件
+func logicDeleteInterceptor(option *ExecOption, next Handler) (any, error) {
+	meta := option.Meta
+	if meta == nil || meta.LogicDelete == "" || meta.Table == "" || meta.Kind != SqlTypeSelect {
+		return next(option)
+	}
+
+	dialect := option.Dialect
+	if dialect == nil {
+		dialect = DefaultDialect
+	}
+	rewrite, err := rewriteLogicDeleteSql(dialect, option.SqlStmt, meta.Table, meta.LogicDelete)
+	if err != nil {
+		return nil, err
+	}
+	if rewrite != nil {
+		rewrite.apply(option, nil)
+	}
+
+	return next(option)
+}
+
+// 为查询语句中的表添加逻辑删除条件, 包括JOIN的表以及子查询中的表, 没有引用该表时返回nil
+func rewriteLogicDeleteSql(dialect Dialect, query, table, column string) (*rewrittenSql, error) {
+	key := dialect.Name() + ":" + table + ":" + column + ":" + query
+	if res, ok := logicDeleteRewriteCache.load(key); ok {
+		return res, nil
+	}
+
+	parsed, err := parseRewriteSql(dialect, query)
+	if err != nil {
+		return nil, fmt.Errorf("vulcan: rewrite sql for logic delete failed, use IncludeDeleted to skip it: %w", err)
+	}
+	stmt, ok := parsed.stmt.(sqlparser.SelectStatement)
+	if !ok {
+		return nil, nil
+	}
+
+	filter := &tableFilter{
+		match: func(name sqlparser.TableName) bool {
+			return strings.EqualFold(name.Name.String(), table) ||
+				!name.Qualifier.IsEmpty() && strings.EqualFold(name.Qualifier.String()+"."+name.Name.String(), table)
+		},
+		condition: func(qualifier sqlparser.TableName) sqlparser.Expr {
+			return &sqlparser.IsExpr{
+				Operator: sqlparser.IsNullStr,
+				Expr:     &sqlparser.ColName{Name: sqlparser.NewColIdent(column), Qualifier: qualifier},
+			}
+		},
+	}
+	changed, err := filter.rewriteSelect(stmt)
+	if err != nil {
+		return nil, fmt.Errorf("vulcan: rewrite sql for logic delete failed, use IncludeDeleted to skip it: %w", err)
+	}
+
+	var res *rewrittenSql
+	if changed {
+		res = formatRewrittenSql(dialect, parsed)
+	}
+	logicDeleteRewriteCache.store(key, res)
+
+	return res, nil
+}
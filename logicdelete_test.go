@@ -0,0 +1,96 @@
+package vulcan
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestRewriteLogicDeleteSql(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{
+			query:    "SELECT * FROM t_user WHERE id = ? OR name = ?",
+			expected: "select * from t_user where (id = ? or name = ?) and t_user.deleted_at is null",
+		},
+		{
+			query:    "SELECT u.*, o.no FROM t_order o LEFT JOIN t_user u ON o.user_id = u.id WHERE o.id IN (SELECT order_id FROM t_user WHERE id = ?)",
+			expected: "select u.*, o.no from t_order as o left join t_user as u on o.user_id = u.id and u.deleted_at is null where o.id in (select order_id from t_user where id = ? and t_user.deleted_at is null)",
+		},
+	}
+	for _, tt := range tests {
+		res, err := rewriteLogicDeleteSql(MySQL, tt.query, "t_user", "deleted_at")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.sql != tt.expected {
+			t.Errorf("rewrite %q\n got: %s\nwant: %s", tt.query, res.sql, tt.expected)
+		}
+	}
+
+	if res, err := rewriteLogicDeleteSql(MySQL, "SELECT * FROM t_order", "t_user", "deleted_at"); err != nil || res != nil {
+		t.Errorf("unexpected rewrite %v, %v", res, err)
+	}
+}
+
+func TestLogicDeleteInterceptor(t *testing.T) {
+	db, state := openFakeDB(t)
+	meta := &StatementMeta{Kind: SqlTypeSelect, Table: "t_user", LogicDelete: "deleted_at"}
+	query := func(opts ...Option) []string {
+		state.Reset()
+		opts = append(opts, withExecer(db), func(o *ExecOption) {
+			o.Args = []any{1}
+			o.Meta = meta
+		})
+		if err := queryStmt("SELECT * FROM t_user WHERE id = ?", opts...); err != nil {
+			t.Fatal(err)
+		}
+		return state.Logs()
+	}
+
+	if logs := query(); !reflect.DeepEqual(logs, []string{"select * from t_user where id = ? and t_user.deleted_at is null"}) {
+		t.Errorf("executed sql = %q", logs)
+	}
+	if logs := query(IncludeDeleted()); !reflect.DeepEqual(logs, []string{"SELECT * FROM t_user WHERE id = ?"}) {
+		t.Errorf("executed sql = %q", logs)
+	}
+}
+
+func TestLogicDeleteWithPagination(t *testing.T) {
+	SetupPaginationInterceptor()
+	defer SetPaginationInterceptor(nil)
+
+	db, state := openFakeDB(t)
+	state.rowsFunc = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"count"}, [][]driver.Value{{int64(25)}}
+	}
+
+	paging := NewPaging(2, 10).AddAscs("id")
+	option := &ExecOption{
+		SqlStmt:   "SELECT username FROM t_user WHERE id > ?",
+		Args:      []any{1},
+		Execer:    db,
+		Extension: paging,
+		Meta:      &StatementMeta{Kind: SqlTypeSelect, Table: "t_user", LogicDelete: "deleted_at"},
+	}
+	_, err := Invoke(option, func() (any, error) {
+		return nil, option.Get().Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 改写后的sql仍然需要分页
+	expected := []string{
+		"select COUNT(*) from t_user where id > ? and t_user.deleted_at is null",
+		"select username from t_user where id > ? and t_user.deleted_at is null ORDER BY id ASC LIMIT 10 OFFSET 10",
+	}
+	if got := state.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	if paging.TotalCount() != 25 {
+		t.Fatalf("unexpected paging %+v", paging)
+	}
+}
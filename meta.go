@@ -0,0 +1,84 @@
+package vulcan
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SqlType sql语句的类型
+type SqlType int
+
+const (
+	SqlTypeUnknown SqlType = iota
+	SqlTypeInsert
+	SqlTypeDelete
+	SqlTypeUpdate
+	SqlTypeSelect
+)
+
+func (s SqlType) String() string {
+	switch s {
+	case SqlTypeInsert:
+		return "INSERT"
+	case SqlTypeDelete:
+		return "DELETE"
+	case SqlTypeUpdate:
+		return "UPDATE"
+	case SqlTypeSelect:
+		return "SELECT"
+	case SqlTypeUnknown:
+		return "UNKNOWN"
+	default:
+		return fmt.Sprintf("SqlType(%d)", int(s))
+	}
+}
+
+// StatementMeta 生成代码时记录的sql语句的元信息, 拦截器可以根据元信息区分mapper方法
+type StatementMeta struct {
+	Mapper      string        // mapper类型名称
+	Method      string        // mapper方法名称
+	Kind        SqlType       // sql语句的类型
+	Table       string        // sql操作的主表名
+	LogicDelete string        // 主表的逻辑删除列, 查询时过滤该列不为NULL的行
+	Source      string        // mapper方法在源文件中的位置, 格式为file:line
+	Dynamic     bool          // 是否为动态sql
+	Sensitive   []int         // 静态sql中敏感参数在Args中的位置, 动态sql的敏感参数通过Sensitive标记
+	Timeout     time.Duration // mapper方法或者模型声明的超时时间, 为0时使用SetDefaultTimeout设置的默认值
+}
+
+// FullMethod 返回Mapper.Method格式的方法名
+func (m *StatementMeta) FullMethod() string {
+	if m == nil {
+		return ""
+	}
+	if m.Mapper == "" {
+		return m.Method
+	}
+
+	return m.Mapper + "." + m.Method
+}
+
+// 获取sql语句的类型, 没有元信息时根据sql语句判断
+func (e *ExecOption) sqlType() SqlType {
+	if e.Meta != nil && e.Meta.Kind != SqlTypeUnknown {
+		return e.Meta.Kind
+	}
+
+	stmt := strings.TrimSpace(e.SqlStmt)
+	if idx := strings.IndexAny(stmt, " \t\n("); idx > 0 {
+		stmt = stmt[:idx]
+	}
+	switch strings.ToUpper(stmt) {
+	case "INSERT", "REPLACE":
+		return SqlTypeInsert
+	case "DELETE":
+		return SqlTypeDelete
+	case "UPDATE":
+		return SqlTypeUpdate
+	case "SELECT", "WITH":
+		return SqlTypeSelect
+	default:
+		return SqlTypeUnknown
+	}
+}
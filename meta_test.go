@@ -0,0 +1,40 @@
+package vulcan
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestStatementMetaInInterceptor(t *testing.T) {
+	db, _ := openFakeDB(t)
+
+	var seen *StatementMeta
+	option := (&ExecOption{
+		SqlStmt: "DELETE FROM t_user WHERE id = ?",
+		Args:    []any{1},
+		Execer:  db,
+		Meta: &StatementMeta{
+			Mapper: "UserMapper",
+			Method: "DeleteById",
+			Kind:   SqlTypeDelete,
+			Table:  "t_user",
+			Source: "usermapper.go:12",
+		},
+	}).Apply(WithInterceptors(func(option *ExecOption, next Handler) (any, error) {
+		seen = option.Meta
+		return next(option)
+	}))
+	_, err := Invoke(option, func() (sql.Result, error) {
+		return option.Exec()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if seen == nil || seen.Kind != SqlTypeDelete || seen.FullMethod() != "UserMapper.DeleteById" || seen.Kind.String() != "DELETE" {
+		t.Fatalf("unexpected meta %+v", seen)
+	}
+	if (*StatementMeta)(nil).FullMethod() != "" {
+		t.Fatal("expected empty method for nil meta")
+	}
+}
@@ -0,0 +1,168 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// FillType 字段自动填充的时机
+type FillType int
+
+const (
+	FillInsert       FillType = 1 << iota // 插入时填充
+	FillUpdate                            // 更新时填充
+	FillInsertUpdate = FillInsert | FillUpdate
+)
+
+func (f FillType) String() string {
+	switch f {
+	case FillInsert:
+		return "INSERT"
+	case FillUpdate:
+		return "UPDATE"
+	case FillInsertUpdate:
+		return "INSERT_UPDATE"
+	default:
+		return fmt.Sprintf("FillType(%d)", int(f))
+	}
+}
+
+// FillField 生成代码中需要自动填充的字段
+type FillField struct {
+	Column string // 列名
+	Value  any    // 指向结构体字段的指针
+}
+
+// MetaFillHandler 自动填充处理器, 生成代码在插入或更新之前通过它获取字段的值
+type MetaFillHandler interface {
+	// Fill 返回column列的填充值, ok为false时不填充
+	Fill(ctx context.Context, fill FillType, column string) (value any, ok bool)
+}
+
+// MetaFillHandlerFunc 使用函数实现MetaFillHandler
+type MetaFillHandlerFunc func(ctx context.Context, fill FillType, column string) (any, bool)
+
+func (f MetaFillHandlerFunc) Fill(ctx context.Context, fill FillType, column string) (any, bool) {
+	return f(ctx, fill, column)
+}
+
+type userKey struct{}
+
+// WithUser 在context中设置当前操作的用户, 默认的填充处理器使用它填充created_by、updated_by等列
+func WithUser(ctx context.Context, user any) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext 获取context中当前操作的用户
+func UserFromContext(ctx context.Context) (any, bool) {
+	user := ctx.Value(userKey{})
+	return user, user != nil
+}
+
+// AuditFillHandler 默认的自动填充处理器
+// 以_at结尾的列填充当前时间, 以_by结尾的列填充当前用户, 没有用户时不填充
+type AuditFillHandler struct {
+	Now  func() time.Time                      // 时钟, 为nil时使用time.Now
+	User func(ctx context.Context) (any, bool) // 获取当前用户, 为nil时使用UserFromContext
+}
+
+func (h *AuditFillHandler) Fill(ctx context.Context, fill FillType, column string) (any, bool) {
+	column = strings.ToLower(column)
+	switch {
+	case strings.HasSuffix(column, "_at"):
+		if h.Now != nil {
+			return h.Now(), true
+		}
+		return time.Now(), true
+	case strings.HasSuffix(column, "_by"):
+		if h.User != nil {
+			return h.User(ctx)
+		}
+		return UserFromContext(ctx)
+	default:
+		return nil, false
+	}
+}
+
+var metaFillHandler MetaFillHandler = &AuditFillHandler{}
+
+// SetMetaFillHandler 设置自动填充处理器, 为nil时不填充任何字段
+func SetMetaFillHandler(handler MetaFillHandler) {
+	metaFillHandler = handler
+}
+
+// FillMeta 生成代码在绑定参数之前调用, 使用MetaFillHandler填充字段
+// 插入时只填充零值字段, 保留调用者设置的值; 更新时总是覆盖字段的值
+// ctx为nil时使用opts中通过WithContext等设置的context
+func FillMeta(ctx context.Context, opts []Option, fill FillType, fields []FillField) error {
+	handler := metaFillHandler
+	if handler == nil || len(fields) == 0 {
+		return nil
+	}
+	if ctx == nil {
+		ctx = (&ExecOption{}).Apply(opts...).Context()
+	}
+
+	for _, field := range fields {
+		value, ok := handler.Fill(ctx, fill, field.Column)
+		if !ok {
+			continue
+		}
+		if err := setFillValue(field.Value, value, fill == FillInsert); err != nil {
+			return fmt.Errorf("vulcan: fill column %s failed: %w", field.Column, err)
+		}
+	}
+
+	return nil
+}
+
+// 将value赋值给ptr指向的字段, 支持sql.Scanner以及指针类型的字段
+func setFillValue(ptr, value any, onlyZero bool) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("field must be a non-nil pointer, got %T", ptr)
+	}
+	field := rv.Elem()
+	if onlyZero && !field.IsZero() {
+		return nil
+	}
+	if scanner, ok := ptr.(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+
+	val := reflect.ValueOf(value)
+	if !val.IsValid() {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	if field.Kind() == reflect.Pointer && !val.Type().AssignableTo(field.Type()) {
+		elem := reflect.New(field.Type().Elem())
+		if err := assignFillValue(elem.Elem(), val); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	return assignFillValue(field, val)
+}
+
+func assignFillValue(dst, val reflect.Value) error {
+	switch {
+	case val.Type().AssignableTo(dst.Type()):
+		dst.Set(val)
+	// 数字填充到字符串字段时格式化为十进制, 而不是转换为对应的字符
+	case dst.Kind() == reflect.String && (val.CanInt() || val.CanUint() || val.CanFloat()):
+		dst.SetString(fmt.Sprint(val.Interface()))
+	case val.Type().ConvertibleTo(dst.Type()):
+		dst.Set(val.Convert(dst.Type()))
+	default:
+		return fmt.Errorf("cannot assign %s to %s", val.Type(), dst.Type())
+	}
+
+	return nil
+}
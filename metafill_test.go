@@ -0,0 +1,53 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestFillMeta(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	old := metaFillHandler
+	SetMetaFillHandler(&AuditFillHandler{Now: func() time.Time { return now }})
+	defer SetMetaFillHandler(old)
+
+	type user struct {
+		CreatedAt time.Time
+		UpdatedAt *time.Time
+		CreatedBy string
+		UpdatedBy sql.NullInt64
+	}
+	created := now.Add(-time.Hour)
+	u := &user{CreatedAt: created, CreatedBy: "admin"}
+	fields := []FillField{
+		{Column: "created_at", Value: &u.CreatedAt},
+		{Column: "updated_at", Value: &u.UpdatedAt},
+		{Column: "created_by", Value: &u.CreatedBy},
+		{Column: "updated_by", Value: &u.UpdatedBy},
+	}
+
+	// 插入时不覆盖已经设置的值, context中没有用户时不填充
+	if err := FillMeta(nil, nil, FillInsert, fields); err != nil {
+		t.Fatal(err)
+	}
+	if !u.CreatedAt.Equal(created) || u.CreatedBy != "admin" || u.UpdatedAt == nil || !u.UpdatedAt.Equal(now) || u.UpdatedBy.Valid {
+		t.Errorf("unexpected insert fill: %+v", u)
+	}
+
+	// 更新时覆盖字段, 使用opts中的context获取用户
+	ctx := WithUser(context.Background(), 7)
+	if err := FillMeta(nil, []Option{WithContext(ctx)}, FillUpdate, fields[2:]); err != nil {
+		t.Fatal(err)
+	}
+	if u.CreatedBy != "7" || u.UpdatedBy != (sql.NullInt64{Int64: 7, Valid: true}) {
+		t.Errorf("unexpected update fill: %+v", u)
+	}
+
+	var count int
+	err := FillMeta(context.Background(), nil, FillUpdate, []FillField{{Column: "updated_at", Value: &count}})
+	if err == nil {
+		t.Error("expected error for incompatible field type")
+	}
+}
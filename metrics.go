@@ -0,0 +1,302 @@
+package vulcan
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets 默认的耗时直方图分桶, 单位为秒
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// 错误分类对应的指标标签
+var errorClasses = map[error]string{
+	ErrNotFound:       "not_found",
+	ErrDuplicateKey:   "duplicate_key",
+	ErrForeignKey:     "foreign_key",
+	ErrDeadlock:       "deadlock",
+	ErrTimeout:        "timeout",
+	ErrOptimisticLock: "optimistic_lock",
+	ErrOverloaded:     "overloaded",
+}
+
+type metricKey struct {
+	method    string
+	table     string
+	operation string
+}
+
+type errorKey struct {
+	metricKey
+	class string
+}
+
+type histogram struct {
+	counts []uint64 // 每个分桶的数量, 不累加
+	sum    float64
+	count  uint64
+}
+
+// Metrics sql执行的指标, 包括耗时直方图、错误数量、返回和影响的行数以及正在执行的sql数量
+// 指标只按照method、table、operation的组合记录, 查询单个方法或单个表的耗时需要对其它标签聚合,
+// 如sum by (table, le) (rate(vulcan_query_duration_seconds_bucket[5m]))
+type Metrics struct {
+	buckets []float64
+
+	mu           sync.Mutex
+	durations    map[metricKey]*histogram
+	errors       map[errorKey]uint64
+	rowsReturned map[metricKey]uint64
+	rowsAffected map[metricKey]uint64
+	inFlight     map[metricKey]int64
+}
+
+// NewMetrics 创建指标收集器, buckets为空时使用DefaultBuckets
+func NewMetrics(buckets []float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &Metrics{
+		buckets:      buckets,
+		durations:    make(map[metricKey]*histogram),
+		errors:       make(map[errorKey]uint64),
+		rowsReturned: make(map[metricKey]uint64),
+		rowsAffected: make(map[metricKey]uint64),
+		inFlight:     make(map[metricKey]int64),
+	}
+}
+
+// DefaultMetrics SetupMetricsInterceptor和MetricsHandler使用的指标收集器
+var DefaultMetrics = NewMetrics(nil)
+
+// SetupMetricsInterceptor 注册指标拦截器, 使用DefaultMetrics收集指标
+func SetupMetricsInterceptor() {
+	RegisterInterceptor(InterceptorMetrics, OrderMetrics, DefaultMetrics.Interceptor())
+}
+
+// MetricsHandler 以Prometheus文本格式输出DefaultMetrics中的指标
+func MetricsHandler() http.Handler {
+	return DefaultMetrics.Handler()
+}
+
+// Interceptor 创建收集指标的拦截器
+func (m *Metrics) Interceptor() InterceptorHandler {
+	return func(option *ExecOption, next Handler) (any, error) {
+		key := metricKey{operation: option.sqlType().String()}
+		if option.Meta != nil {
+			key.method = option.Meta.FullMethod()
+			key.table = option.Meta.Table
+		}
+
+		m.mu.Lock()
+		m.inFlight[key]++
+		m.mu.Unlock()
+		// 后续拦截器或执行函数panic时也要减少正在执行的数量
+		defer func() {
+			m.mu.Lock()
+			m.inFlight[key]--
+			m.mu.Unlock()
+		}()
+
+		start := time.Now()
+		res, err := next(option)
+		m.observe(key, time.Since(start), res, err, option.Dialect)
+
+		return res, err
+	}
+}
+
+func (m *Metrics) observe(key metricKey, elapsed time.Duration, res any, err error, dialect Dialect) {
+	class := ""
+	if err != nil {
+		class = errorClass(dialect, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h := m.durations[key]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.durations[key] = h
+	}
+	seconds := elapsed.Seconds()
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += seconds
+	h.count++
+
+	if err != nil {
+		m.errors[errorKey{metricKey: key, class: class}]++
+		return
+	}
+	if result, ok := res.(sql.Result); ok {
+		if affected, e := result.RowsAffected(); e == nil {
+			m.rowsAffected[key] += uint64(affected)
+		}
+	} else if rows, ok := resultRows(res); ok {
+		m.rowsReturned[key] += uint64(rows)
+	}
+}
+
+// 获取错误分类的标签, 乐观锁冲突和过载错误由拦截器直接返回, 不经过ClassifyError分类
+func errorClass(dialect Dialect, err error) string {
+	for _, kind := range []error{ErrOptimisticLock, ErrOverloaded} {
+		if errors.Is(err, kind) {
+			return errorClasses[kind]
+		}
+	}
+	if class, ok := errorClasses[ClassifyError(dialect, err)]; ok {
+		return class
+	}
+
+	return "other"
+}
+
+// Handler 以Prometheus文本格式输出指标的http.Handler
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteTo(w)
+	})
+}
+
+// WriteTo 以Prometheus文本格式输出指标
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	cw.header("vulcan_query_duration_seconds", "histogram", "SQL execution latency in seconds.")
+	for _, key := range sortedKeys(m.durations) {
+		h := m.durations[key]
+		labels := key.labels()
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += h.counts[i]
+			cw.printf("vulcan_query_duration_seconds_bucket{%s,le=%q} %d\n", labels, formatFloat(bound), cumulative)
+		}
+		cw.printf("vulcan_query_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		cw.printf("vulcan_query_duration_seconds_sum{%s} %s\n", labels, formatFloat(h.sum))
+		cw.printf("vulcan_query_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	cw.header("vulcan_query_errors_total", "counter", "SQL execution errors by class.")
+	errKeys := make([]errorKey, 0, len(m.errors))
+	for key := range m.errors {
+		errKeys = append(errKeys, key)
+	}
+	sort.Slice(errKeys, func(i, j int) bool {
+		if errKeys[i].metricKey != errKeys[j].metricKey {
+			return errKeys[i].metricKey.less(errKeys[j].metricKey)
+		}
+		return errKeys[i].class < errKeys[j].class
+	})
+	for _, key := range errKeys {
+		cw.printf("vulcan_query_errors_total{%s,class=%s} %d\n", key.labels(), quoteLabel(key.class), m.errors[key])
+	}
+
+	cw.header("vulcan_rows_returned_total", "counter", "Rows returned by queries.")
+	for _, key := range sortedKeys(m.rowsReturned) {
+		cw.printf("vulcan_rows_returned_total{%s} %d\n", key.labels(), m.rowsReturned[key])
+	}
+
+	cw.header("vulcan_rows_affected_total", "counter", "Rows affected by insert, update and delete statements.")
+	for _, key := range sortedKeys(m.rowsAffected) {
+		cw.printf("vulcan_rows_affected_total{%s} %d\n", key.labels(), m.rowsAffected[key])
+	}
+
+	cw.header("vulcan_queries_in_flight", "gauge", "SQL statements currently executing.")
+	for _, key := range sortedKeys(m.inFlight) {
+		cw.printf("vulcan_queries_in_flight{%s} %d\n", key.labels(), m.inFlight[key])
+	}
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+
+	return cw.n, cw.err
+}
+
+// Reset 清空收集的指标
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.durations = make(map[metricKey]*histogram)
+	m.errors = make(map[errorKey]uint64)
+	m.rowsReturned = make(map[metricKey]uint64)
+	m.rowsAffected = make(map[metricKey]uint64)
+	m.inFlight = make(map[metricKey]int64)
+}
+
+func (k metricKey) labels() string {
+	return fmt.Sprintf("method=%s,table=%s,operation=%s", quoteLabel(k.method), quoteLabel(k.table), quoteLabel(k.operation))
+}
+
+func (k metricKey) less(o metricKey) bool {
+	if k.method != o.method {
+		return k.method < o.method
+	}
+	if k.table != o.table {
+		return k.table < o.table
+	}
+	return k.operation < o.operation
+}
+
+// 按照标签排序, 保证输出顺序稳定
+func sortedKeys[V any](m map[metricKey]V) []metricKey {
+	keys := make([]metricKey, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].less(keys[j])
+	})
+
+	return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func quoteLabel(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) printf(format string, args ...any) {
+	if c.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(c.w, format, args...)
+	c.n += int64(n)
+	c.err = err
+}
+
+func (c *countingWriter) header(name, typ, help string) {
+	c.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
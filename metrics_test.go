@@ -0,0 +1,115 @@
+package vulcan
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestMetricsInterceptor(t *testing.T) {
+	metrics := NewMetrics([]float64{0.5, 0.1})
+	db, state := openFakeDB(t)
+	state.affectedFunc = func(query string) int64 {
+		return 3
+	}
+
+	meta := &StatementMeta{Mapper: "UserMapper", Method: "UpdateAge", Kind: SqlTypeUpdate, Table: "t_user"}
+	exec := func() error {
+		option := (&ExecOption{SqlStmt: "UPDATE t_user SET age = ?", Args: []any{1}, Execer: db, Meta: meta}).
+			Apply(WithInterceptors(metrics.Interceptor()))
+		_, err := Invoke(option, func() (sql.Result, error) {
+			return option.Exec()
+		})
+		return err
+	}
+	for i := 0; i < 2; i++ {
+		if err := exec(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	state.errFunc = func(query string) error {
+		return &mysql.MySQLError{Number: mysqlErrLockDeadlock}
+	}
+	if err := exec(); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// 执行函数panic时正在执行的数量也要恢复
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic")
+			}
+		}()
+		option := (&ExecOption{SqlStmt: "UPDATE t_user SET age = ?", Execer: db, Meta: meta}).Apply(WithInterceptors(metrics.Interceptor()))
+		_, _ = Invoke(option, func() (sql.Result, error) {
+			panic("scan failed")
+		})
+	}()
+
+	// 没有元信息时只有sql类型标签
+	option := (&ExecOption{SqlStmt: "SELECT id FROM t_user", Execer: db}).Apply(WithInterceptors(metrics.Interceptor()))
+	if _, err := Invoke(option, func() ([]int, error) {
+		return []int{1, 2, 3, 4}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Body)
+	output := string(body)
+
+	labels := `method="UserMapper.UpdateAge",table="t_user",operation="UPDATE"`
+	expected := []string{
+		"# TYPE vulcan_query_duration_seconds histogram",
+		`vulcan_query_duration_seconds_bucket{` + labels + `,le="0.1"} 3`,
+		`vulcan_query_duration_seconds_bucket{` + labels + `,le="+Inf"} 3`,
+		`vulcan_query_duration_seconds_count{` + labels + `} 3`,
+		`vulcan_query_errors_total{` + labels + `,class="deadlock"} 1`,
+		`vulcan_rows_affected_total{` + labels + `} 6`,
+		`vulcan_rows_returned_total{method="",table="",operation="SELECT"} 4`,
+		`vulcan_queries_in_flight{` + labels + `} 0`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("expected output to contain %q\n%s", line, output)
+		}
+	}
+	if ct := recorder.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %s", ct)
+	}
+}
+
+func TestMetricsErrorClasses(t *testing.T) {
+	metrics := NewMetrics(nil)
+	interceptor := metrics.Interceptor()
+	option := &ExecOption{SqlStmt: "UPDATE t_user SET age = ?", Meta: &StatementMeta{Mapper: "UserMapper", Method: "UpdateAge", Table: "t_user"}}
+	for _, err := range []error{ErrOptimisticLock, ErrOverloaded, fmt.Errorf("wrapped: %w", ErrOverloaded), &mysql.MySQLError{Number: mysqlErrDupEntry}, errors.New("unknown")} {
+		_, _ = interceptor(option, func(option *ExecOption) (any, error) {
+			return nil, err
+		})
+	}
+
+	var buf strings.Builder
+	if _, err := metrics.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	labels := `method="UserMapper.UpdateAge",table="t_user",operation="UPDATE"`
+	for _, line := range []string{
+		`vulcan_query_errors_total{` + labels + `,class="optimistic_lock"} 1`,
+		`vulcan_query_errors_total{` + labels + `,class="overloaded"} 2`,
+		`vulcan_query_errors_total{` + labels + `,class="duplicate_key"} 1`,
+		`vulcan_query_errors_total{` + labels + `,class="other"} 1`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("expected output to contain %q\n%s", line, buf.String())
+		}
+	}
+}
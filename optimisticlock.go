@@ -0,0 +1,169 @@
+package vulcan
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/blastrain/vitess-sqlparser/sqlparser"
+)
+
+// OptimisticLock 乐观锁, 生成代码时根据模型中的版本字段设置
+// 执行UPDATE语句时如果sql中没有版本条件, 会在WHERE子句中添加version = ?并在SET子句中添加version = version + 1
+// 更新的行数为0时返回ErrOptimisticLock, 更新成功后Version指向的版本号加1
+// 不会单独查询记录是否存在, 更新的记录不存在时同样返回ErrOptimisticLock, 需要区分时由调用方查询
+type OptimisticLock struct {
+	Column  string // 版本列名
+	Version any    // 指向结构体版本字段的指针, 字段必须为整数类型
+}
+
+var versionRewriteCache rewriteCache
+
+// 乐观锁拦截器, 默认注册, 没有设置OptimisticLock时直接执行
+func optimisticLockInterceptor(option *ExecOption, next Handler) (any, error) {
+	lock := option.OptimisticLock
+	if lock == nil {
+		return next(option)
+	}
+
+	version, err := lock.version()
+	if err != nil {
+		return nil, err
+	}
+	dialect := option.Dialect
+	if dialect == nil {
+		dialect = DefaultDialect
+	}
+	rewrite, err := rewriteVersionSql(dialect, option.SqlStmt, lock.Column)
+	if err != nil {
+		return nil, err
+	}
+	if rewrite != nil {
+		rewrite.apply(option, version.Interface())
+	}
+
+	res, err := next(option)
+	if err != nil {
+		return res, err
+	}
+	result, ok := res.(sql.Result)
+	if !ok {
+		return res, nil
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return res, err
+	}
+	if affected == 0 {
+		return res, ErrOptimisticLock
+	}
+	increaseVersion(version)
+
+	return res, nil
+}
+
+// 获取版本字段
+func (l *OptimisticLock) version() (reflect.Value, error) {
+	rv := reflect.ValueOf(l.Version)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return reflect.Value{}, fmt.Errorf("vulcan: optimistic lock version of column %s must be a non-nil pointer, got %T", l.Column, l.Version)
+	}
+	switch rv.Elem().Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Elem(), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("vulcan: optimistic lock version of column %s must be an integer, got %T", l.Column, l.Version)
+}
+
+func increaseVersion(version reflect.Value) {
+	if version.CanInt() {
+		version.SetInt(version.Int() + 1)
+	} else {
+		version.SetUint(version.Uint() + 1)
+	}
+}
+
+// 为UPDATE语句添加版本条件以及版本号自增, sql中已经包含时不需要改写, 返回nil
+func rewriteVersionSql(dialect Dialect, query, column string) (*rewrittenSql, error) {
+	key := dialect.Name() + ":" + column + ":" + query
+	if res, ok := versionRewriteCache.load(key); ok {
+		return res, nil
+	}
+
+	parsed, err := parseRewriteSql(dialect, query)
+	if err == nil {
+		if _, ok := parsed.stmt.(*sqlparser.Update); !ok {
+			err = errors.New("not an UPDATE statement")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vulcan: rewrite sql for optimistic lock failed: %w", err)
+	}
+
+	update := parsed.stmt.(*sqlparser.Update)
+	changed := false
+	col := sqlparser.NewColIdent(column)
+	if !hasVersionSet(update.Exprs, col) {
+		update.Exprs = append(update.Exprs, &sqlparser.UpdateExpr{
+			Name: &sqlparser.ColName{Name: col},
+			Expr: &sqlparser.BinaryExpr{
+				Operator: sqlparser.PlusStr,
+				Left:     &sqlparser.ColName{Name: col},
+				Right:    sqlparser.NewIntVal([]byte("1")),
+			},
+		})
+		changed = true
+	}
+	if !hasVersionCondition(update.Where, col) {
+		update.Where = andWhere(update.Where, &sqlparser.ComparisonExpr{
+			Operator: sqlparser.EqualStr,
+			Left:     &sqlparser.ColName{Name: col},
+			Right:    injectedArg(),
+		})
+		changed = true
+	}
+
+	var res *rewrittenSql
+	if changed {
+		res = formatRewrittenSql(dialect, parsed)
+	}
+	versionRewriteCache.store(key, res)
+
+	return res, nil
+}
+
+func hasVersionSet(exprs sqlparser.UpdateExprs, col sqlparser.ColIdent) bool {
+	for _, expr := range exprs {
+		if expr.Name.Name.Equal(col) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WHERE子句中是否有version = ?条件, 只检查使用AND连接的条件, 列名不区分大小写
+func hasVersionCondition(where *sqlparser.Where, col sqlparser.ColIdent) bool {
+	if where == nil {
+		return false
+	}
+
+	var find func(expr sqlparser.Expr) bool
+	find = func(expr sqlparser.Expr) bool {
+		switch expr := expr.(type) {
+		case *sqlparser.AndExpr:
+			return find(expr.Left) || find(expr.Right)
+		case *sqlparser.ParenExpr:
+			return find(expr.Expr)
+		case *sqlparser.ComparisonExpr:
+			name, ok := expr.Left.(*sqlparser.ColName)
+			return ok && expr.Operator == sqlparser.EqualStr && name.Name.Equal(col)
+		}
+		return false
+	}
+
+	return find(where.Expr)
+}
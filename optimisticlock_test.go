@@ -0,0 +1,94 @@
+package vulcan
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRewriteVersionSql(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		query    string
+		expected string
+		args     []int
+	}{
+		{
+			dialect:  MySQL,
+			query:    "UPDATE t_user SET name = ? WHERE id = ? OR name = ?",
+			expected: "update t_user set name = ?, version = version + 1 where (id = ? or name = ?) and version = ?",
+			args:     []int{0, 1, 2, -1},
+		},
+		{
+			dialect:  Postgres,
+			query:    "UPDATE t_user SET \"Version\" = \"Version\" + 1 WHERE id = ? RETURNING id",
+			expected: "update t_user set \"Version\" = \"Version\" + 1 where id = ? and version = ? RETURNING id",
+			args:     []int{0, -1},
+		},
+	}
+	for _, tt := range tests {
+		res, err := rewriteVersionSql(tt.dialect, tt.query, "version")
+		if err != nil {
+			t.Fatalf("rewrite %q: %v", tt.query, err)
+		}
+		if res.sql != tt.expected || !reflect.DeepEqual(res.args, tt.args) {
+			t.Errorf("rewrite %q\n got: %s %v\nwant: %s %v", tt.query, res.sql, res.args, tt.expected, tt.args)
+		}
+	}
+
+	// 生成代码中已经包含版本条件的sql不需要改写
+	res, err := rewriteVersionSql(MySQL, "UPDATE t_user SET name=?,version=version+1 WHERE id = ? AND version = ?", "version")
+	if err != nil || res != nil {
+		t.Errorf("unexpected rewrite %v, %v", res, err)
+	}
+	if _, err = rewriteVersionSql(MySQL, "DELETE FROM t_user WHERE id = ?", "version"); err == nil {
+		t.Error("expected error for DELETE statement")
+	}
+}
+
+func TestOptimisticLockInterceptor(t *testing.T) {
+	db, state := openFakeDB(t)
+	var affected int64
+	state.affectedFunc = func(query string) int64 {
+		return affected
+	}
+
+	type user struct {
+		Id      int64
+		Name    string
+		Version uint32
+	}
+	u := &user{Id: 1, Name: "mango", Version: 3}
+	update := func() error {
+		return execStmt("UPDATE t_user SET name = ? WHERE id = ?", withExecer(db), func(o *ExecOption) {
+			o.Args = []any{u.Name, u.Id}
+			o.OptimisticLock = &OptimisticLock{Column: "version", Version: &u.Version}
+		})
+	}
+
+	affected = 1
+	if err := update(); err != nil {
+		t.Fatal(err)
+	}
+	if logs := state.Logs(); len(logs) != 1 || logs[0] != "update t_user set name = ?, version = version + 1 where id = ? and version = ?" {
+		t.Errorf("executed sql = %q", logs)
+	}
+	if u.Version != 4 {
+		t.Errorf("version = %d, expected 4", u.Version)
+	}
+
+	affected = 0
+	if err := update(); !errors.Is(err, ErrOptimisticLock) {
+		t.Errorf("expected ErrOptimisticLock, got %v", err)
+	}
+	if u.Version != 4 {
+		t.Errorf("version should not change on conflict, got %d", u.Version)
+	}
+
+	err := execStmt("UPDATE t_user SET name = ?", withExecer(db), func(o *ExecOption) {
+		o.OptimisticLock = &OptimisticLock{Column: "version", Version: u.Version}
+	})
+	if err == nil {
+		t.Error("expected error for non-pointer version")
+	}
+}
@@ -0,0 +1,390 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // 关闭, 正常执行
+	BreakerOpen                         // 打开, 直接返回ErrOverloaded
+	BreakerHalfOpen                     // 半开, 允许少量探测请求, 全部成功后关闭
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+// BreakerOptions 熔断器选项, 零值字段使用默认值
+type BreakerOptions struct {
+	Window           time.Duration        // 统计错误率的时间窗口, 默认为10s
+	MinRequests      int                  // 窗口内的请求数达到该值后才会打开熔断器, 默认为20
+	ErrorRate        float64              // 窗口内的错误率达到该值时打开熔断器, 取值(0, 1], 默认为0.5
+	OpenTimeout      time.Duration        // 打开后经过该时间进入半开状态, 默认为5s
+	HalfOpenRequests int                  // 半开状态允许的探测请求数量, 默认为1
+	IsFailure        func(err error) bool // 判断错误是否计入错误率, 默认为IsBreakerFailure
+	// OnStateChange 熔断器状态变化时调用, name为数据源名称
+	OnStateChange func(name string, from, to BreakerState)
+}
+
+func (o *BreakerOptions) withDefaults() BreakerOptions {
+	res := *o
+	if res.Window <= 0 {
+		res.Window = 10 * time.Second
+	}
+	if res.MinRequests <= 0 {
+		res.MinRequests = 20
+	}
+	if res.ErrorRate <= 0 || res.ErrorRate > 1 {
+		res.ErrorRate = 0.5
+	}
+	if res.OpenTimeout <= 0 {
+		res.OpenTimeout = 5 * time.Second
+	}
+	if res.HalfOpenRequests <= 0 {
+		res.HalfOpenRequests = 1
+	}
+	if res.IsFailure == nil {
+		res.IsFailure = IsBreakerFailure
+	}
+
+	return res
+}
+
+// OverloadOptions 并发限制以及熔断选项
+type OverloadOptions struct {
+	MaxConcurrency        int            // 每个数据源同时执行的sql数量, 0表示不限制
+	DataSourceConcurrency map[string]int // 指定数据源同时执行的sql数量, 覆盖MaxConcurrency
+	MaxMethodConcurrency  int            // 每个mapper方法同时执行的sql数量, 0表示不限制
+	MethodConcurrency     map[string]int // 指定mapper方法同时执行的sql数量, key为Mapper.Method, 覆盖MaxMethodConcurrency
+	QueueTimeout          time.Duration  // 等待执行的最长时间, 超过后返回ErrOverloaded, 默认为100ms
+	Breaker               *BreakerOptions
+}
+
+// IsBreakerFailure 判断错误是否计入熔断器的错误率
+// 没有结果、违反约束、乐观锁冲突等业务错误以及context取消不计入
+func IsBreakerFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrOverloaded) ||
+		errors.Is(err, ErrOptimisticLock) || errors.Is(err, ErrNoTenant) {
+		return false
+	}
+
+	kind := ClassifyError(nil, err)
+	if kind == nil {
+		kind = err
+	}
+	for _, target := range []error{ErrNotFound, ErrDuplicateKey, ErrForeignKey} {
+		if errors.Is(kind, target) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// OverloadProtector 按照数据源以及mapper方法限制同时执行的sql数量, 并为每个数据源维护一个熔断器
+type OverloadProtector struct {
+	options OverloadOptions
+	breaker *BreakerOptions // 设置默认值之后的熔断器选项, 为nil时不开启熔断
+
+	limiters sync.Map // key为数据源名称或者Mapper.Method, value为chan struct{}
+	breakers sync.Map // key为数据源名称, value为*circuitBreaker
+}
+
+// NewOverloadProtector 创建并发限制以及熔断器
+func NewOverloadProtector(options OverloadOptions) *OverloadProtector {
+	if options.QueueTimeout <= 0 {
+		options.QueueTimeout = 100 * time.Millisecond
+	}
+	p := &OverloadProtector{options: options}
+	if options.Breaker != nil {
+		breaker := options.Breaker.withDefaults()
+		p.breaker = &breaker
+	}
+
+	return p
+}
+
+// SetupOverloadInterceptor 注册并发限制以及熔断拦截器
+func SetupOverloadInterceptor(options OverloadOptions) *OverloadProtector {
+	p := NewOverloadProtector(options)
+	RegisterInterceptor(InterceptorOverload, OrderOverload, p.Interceptor())
+
+	return p
+}
+
+// BreakerState 获取数据源熔断器的状态
+func (p *OverloadProtector) BreakerState(dataSource string) BreakerState {
+	if b, ok := p.breakers.Load(dataSource); ok {
+		return b.(*circuitBreaker).currentState(time.Now())
+	}
+
+	return BreakerClosed
+}
+
+// Interceptor 创建并发限制以及熔断拦截器
+// 事务中的sql已经持有连接, 只计入熔断器的错误率, 不限制并发
+func (p *OverloadProtector) Interceptor() InterceptorHandler {
+	return func(option *ExecOption, next Handler) (any, error) {
+		name := overloadDataSourceName(option)
+		var breaker *circuitBreaker
+		var generation uint64
+		if p.breaker != nil {
+			breaker = p.loadBreaker(name)
+			gen, err := breaker.allow(time.Now())
+			if err != nil {
+				return nil, err
+			}
+			generation = gen
+		}
+
+		if _, inTx := option.Execer.(*sql.Tx); !inTx {
+			release, err := p.acquire(option, name)
+			if err != nil {
+				if breaker != nil {
+					breaker.done(generation, ErrOverloaded, time.Now())
+				}
+				return nil, err
+			}
+			defer release()
+		}
+
+		res, err := next(option)
+		if breaker != nil {
+			breaker.done(generation, err, time.Now())
+		}
+
+		return res, err
+	}
+}
+
+// 执行sql的数据源名称, 使用没有注册的Execer时为空字符串
+func overloadDataSourceName(option *ExecOption) string {
+	if option.DataSource != "" {
+		return option.DataSource
+	}
+	if ds := findDataSource("", option.Execer); ds != nil {
+		return ds.name
+	}
+
+	return ""
+}
+
+// 依次获取数据源以及mapper方法的执行许可
+func (p *OverloadProtector) acquire(option *ExecOption, name string) (func(), error) {
+	var sems []chan struct{}
+	if limit, ok := p.options.DataSourceConcurrency[name]; ok || p.options.MaxConcurrency > 0 {
+		if !ok {
+			limit = p.options.MaxConcurrency
+		}
+		if limit > 0 {
+			sems = append(sems, p.loadLimiter("datasource:"+name, limit))
+		}
+	}
+	if method := option.Meta.FullMethod(); method != "" {
+		limit, ok := p.options.MethodConcurrency[method]
+		if !ok {
+			limit = p.options.MaxMethodConcurrency
+		}
+		if limit > 0 {
+			sems = append(sems, p.loadLimiter("method:"+method, limit))
+		}
+	}
+
+	release := func(n int) {
+		for i := n - 1; i >= 0; i-- {
+			<-sems[i]
+		}
+	}
+	var timer *time.Timer
+	for i, sem := range sems {
+		select {
+		case sem <- struct{}{}:
+			continue
+		default:
+		}
+
+		if timer == nil {
+			timer = time.NewTimer(p.options.QueueTimeout)
+			defer timer.Stop()
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-timer.C:
+			release(i)
+			return nil, ErrOverloaded
+		case <-option.Context().Done():
+			release(i)
+			return nil, option.Context().Err()
+		}
+	}
+
+	return func() {
+		release(len(sems))
+	}, nil
+}
+
+func (p *OverloadProtector) loadLimiter(key string, limit int) chan struct{} {
+	if sem, ok := p.limiters.Load(key); ok {
+		return sem.(chan struct{})
+	}
+	sem, _ := p.limiters.LoadOrStore(key, make(chan struct{}, limit))
+
+	return sem.(chan struct{})
+}
+
+func (p *OverloadProtector) loadBreaker(name string) *circuitBreaker {
+	if b, ok := p.breakers.Load(name); ok {
+		return b.(*circuitBreaker)
+	}
+	b, _ := p.breakers.LoadOrStore(name, &circuitBreaker{name: name, options: p.breaker})
+
+	return b.(*circuitBreaker)
+}
+
+// 熔断器, 在固定的时间窗口内统计错误率
+type circuitBreaker struct {
+	name    string
+	options *BreakerOptions
+
+	mu          sync.Mutex
+	state       BreakerState
+	generation  uint64 // 每次状态变化或者窗口重置时加1, 忽略之前放行的请求的结果
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int // 半开状态已经放行的探测请求数量
+	successes   int // 半开状态成功的探测请求数量
+}
+
+type breakerTransition struct {
+	from, to BreakerState
+}
+
+// 判断是否放行请求, 返回放行时的generation
+func (b *circuitBreaker) allow(now time.Time) (uint64, error) {
+	b.mu.Lock()
+	var transitions []breakerTransition
+	defer func() {
+		b.mu.Unlock()
+		b.notify(transitions)
+	}()
+
+	if b.state == BreakerOpen {
+		if now.Sub(b.openedAt) < b.options.OpenTimeout {
+			return 0, ErrOverloaded
+		}
+		transitions = append(transitions, b.setState(BreakerHalfOpen, now))
+	}
+	switch b.state {
+	case BreakerHalfOpen:
+		if b.probes >= b.options.HalfOpenRequests {
+			return 0, ErrOverloaded
+		}
+		b.probes++
+	case BreakerClosed:
+		if now.Sub(b.windowStart) >= b.options.Window {
+			b.resetWindow(now)
+		}
+	}
+
+	return b.generation, nil
+}
+
+// 记录请求的结果, 没有执行或者被取消的请求传入ErrOverloaded或context.Canceled, 只释放半开状态的探测名额
+func (b *circuitBreaker) done(generation uint64, err error, now time.Time) {
+	b.mu.Lock()
+	var transitions []breakerTransition
+	defer func() {
+		b.mu.Unlock()
+		b.notify(transitions)
+	}()
+
+	if generation != b.generation {
+		return
+	}
+	if errors.Is(err, ErrOverloaded) || errors.Is(err, context.Canceled) {
+		if b.state == BreakerHalfOpen {
+			b.probes--
+		}
+		return
+	}
+
+	failed := b.options.IsFailure(err)
+	switch b.state {
+	case BreakerHalfOpen:
+		if failed {
+			transitions = append(transitions, b.setState(BreakerOpen, now))
+			return
+		}
+		b.successes++
+		if b.successes >= b.options.HalfOpenRequests {
+			transitions = append(transitions, b.setState(BreakerClosed, now))
+		}
+	case BreakerClosed:
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.options.MinRequests && float64(b.failures)/float64(b.requests) >= b.options.ErrorRate {
+			transitions = append(transitions, b.setState(BreakerOpen, now))
+		}
+	}
+}
+
+func (b *circuitBreaker) currentState(now time.Time) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.options.OpenTimeout {
+		return BreakerHalfOpen
+	}
+
+	return b.state
+}
+
+func (b *circuitBreaker) setState(state BreakerState, now time.Time) breakerTransition {
+	transition := breakerTransition{from: b.state, to: state}
+	b.state = state
+	b.probes = 0
+	b.successes = 0
+	if state == BreakerOpen {
+		b.openedAt = now
+	}
+	b.resetWindow(now)
+
+	return transition
+}
+
+func (b *circuitBreaker) resetWindow(now time.Time) {
+	b.generation++
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+}
+
+// 在锁外调用状态变化的回调函数
+func (b *circuitBreaker) notify(transitions []breakerTransition) {
+	if b.options.OnStateChange == nil {
+		return
+	}
+	for _, t := range transitions {
+		b.options.OnStateChange(b.name, t.from, t.to)
+	}
+}
@@ -0,0 +1,124 @@
+package vulcan
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOverloadLimiter(t *testing.T) {
+	p := NewOverloadProtector(OverloadOptions{
+		MaxMethodConcurrency: 1,
+		QueueTimeout:         20 * time.Millisecond,
+	})
+	interceptor := p.Interceptor()
+	newOption := func(method string) *ExecOption {
+		return &ExecOption{Ctx: context.Background(), Meta: &StatementMeta{Mapper: "UserMapper", Method: method}}
+	}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		interceptor(newOption("Add"), func(option *ExecOption) (any, error) {
+			close(started)
+			<-release
+			return nil, nil
+		})
+	}()
+	<-started
+
+	called := false
+	next := func(option *ExecOption) (any, error) {
+		called = true
+		return nil, nil
+	}
+	if _, err := interceptor(newOption("Add"), next); !errors.Is(err, ErrOverloaded) || called {
+		t.Errorf("expected ErrOverloaded without executing, got %v", err)
+	}
+	// 其它方法不受影响
+	if _, err := interceptor(newOption("Delete"), next); err != nil || !called {
+		t.Errorf("unexpected result %v, called: %v", err, called)
+	}
+
+	close(release)
+	wg.Wait()
+	called = false
+	if _, err := interceptor(newOption("Add"), next); err != nil || !called {
+		t.Errorf("unexpected result after release %v, called: %v", err, called)
+	}
+}
+
+func TestOverloadBreaker(t *testing.T) {
+	var mu sync.Mutex
+	var transitions []string
+	p := NewOverloadProtector(OverloadOptions{
+		Breaker: &BreakerOptions{
+			MinRequests: 2,
+			ErrorRate:   0.6,
+			OpenTimeout: 20 * time.Millisecond,
+			OnStateChange: func(name string, from, to BreakerState) {
+				mu.Lock()
+				defer mu.Unlock()
+				transitions = append(transitions, name+":"+from.String()+"->"+to.String())
+			},
+		},
+	})
+	interceptor := p.Interceptor()
+	option := &ExecOption{Ctx: context.Background(), DataSource: "orders"}
+	exec := func(err error) (bool, error) {
+		called := false
+		_, res := interceptor(option, func(option *ExecOption) (any, error) {
+			called = true
+			return nil, err
+		})
+		return called, res
+	}
+
+	// 业务错误不计入错误率
+	exec(ErrNotFound)
+	exec(errors.New("connection refused"))
+	if p.BreakerState("orders") != BreakerClosed {
+		t.Fatalf("breaker should be closed, got %s", p.BreakerState("orders"))
+	}
+	exec(errors.New("connection refused"))
+	if p.BreakerState("orders") != BreakerOpen {
+		t.Fatalf("breaker should be open, got %s", p.BreakerState("orders"))
+	}
+	if called, err := exec(nil); called || !errors.Is(err, ErrOverloaded) {
+		t.Errorf("open breaker should reject, called: %v, err: %v", called, err)
+	}
+
+	// 半开状态探测失败后重新打开, 探测成功后关闭
+	time.Sleep(30 * time.Millisecond)
+	if called, _ := exec(errors.New("connection refused")); !called {
+		t.Error("half-open breaker should allow a probe")
+	}
+	if p.BreakerState("orders") != BreakerOpen {
+		t.Fatalf("breaker should be open, got %s", p.BreakerState("orders"))
+	}
+	time.Sleep(30 * time.Millisecond)
+	if called, err := exec(nil); !called || err != nil {
+		t.Errorf("probe failed, called: %v, err: %v", called, err)
+	}
+	if p.BreakerState("orders") != BreakerClosed {
+		t.Errorf("breaker should be closed, got %s", p.BreakerState("orders"))
+	}
+
+	expected := []string{
+		"orders:closed->open",
+		"orders:open->half-open",
+		"orders:half-open->open",
+		"orders:open->half-open",
+		"orders:half-open->closed",
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(transitions, expected) {
+		t.Errorf("transitions = %q", transitions)
+	}
+}
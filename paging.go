@@ -1,6 +1,7 @@
 package vulcan
 
 import (
+	"regexp"
 	"strings"
 )
 
@@ -13,8 +14,12 @@ type OrderItems []OrderItem
 
 func (o OrderItems) SqlStmt() string {
 	builder := strings.Builder{}
-	for _, order := range o {
-		builder.WriteString(" ORDER BY ")
+	for i, order := range o {
+		if i == 0 {
+			builder.WriteString(" ORDER BY ")
+		} else {
+			builder.WriteString(", ")
+		}
 		builder.WriteString(order.Column)
 		if order.Desc {
 			builder.WriteString(" DESC")
@@ -115,9 +120,15 @@ func (p *Paging) IsSelectCount() bool {
 	return true
 }
 
+var (
+	selectKeywordRegex = regexp.MustCompile(`(?i)\bSELECT\b`)
+	fromKeywordRegex   = regexp.MustCompile(`(?i)\bFROM\b`)
+)
+
+// 拦截器改写后的sql为小写, 不区分大小写查找关键字
 func (p *Paging) GetSelectCountSql(originSql string) string {
-	start := strings.Index(originSql, "SELECT") + 6
-	end := strings.Index(originSql, "FROM")
+	start := selectKeywordRegex.FindStringIndex(originSql)[1]
+	end := fromKeywordRegex.FindStringIndex(originSql)[0]
 	return originSql[:start] + " COUNT(*) " + originSql[end:]
 }
 
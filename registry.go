@@ -0,0 +1,261 @@
+package vulcan
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// 内置拦截器的名称
+const (
+	InterceptorTracing        = "tracing"         // 链路追踪拦截器
+	InterceptorMetrics        = "metrics"         // 指标拦截器
+	InterceptorOverload       = "overload"        // 并发限制以及熔断拦截器
+	InterceptorOptimisticLock = "optimistic-lock" // 乐观锁拦截器
+	InterceptorTenant         = "tenant"          // 多租户sql改写拦截器
+	InterceptorLogicDelete    = "logic-delete"    // 逻辑删除拦截器
+	InterceptorCache          = "cache"           // CacheableCtx和CacheEvictCtx指定的缓存拦截器
+	InterceptorPagination     = "pagination"      // 分页拦截器
+	InterceptorSqlDebug       = "sql-debug"       // sql调试日志拦截器
+	InterceptorContext        = "context"         // 通过WithInterceptors传入的拦截器
+	InterceptorSlowQuery      = "slow-query"      // 慢查询日志拦截器
+)
+
+// 内置拦截器的执行顺序, order越小越先执行, order相同时按照注册顺序执行
+const (
+	OrderTracing        = -400 // 链路追踪拦截器最先执行, span包含缓存和分页的耗时
+	OrderMetrics        = -350
+	OrderOverload       = -340 // 在指标拦截器之后执行, 拒绝的请求也会记录到指标中
+	OrderOptimisticLock = -330
+	OrderTenant         = -320 // 多租户改写在缓存、分页以及日志之前执行
+	OrderLogicDelete    = -310
+	OrderCache          = -300
+	OrderPagination     = -200
+	OrderSqlDebug       = -100
+	OrderDefault        = 0
+	OrderContext        = 100
+	OrderSlowQuery      = 1000
+)
+
+type namedInterceptor struct {
+	name    string
+	order   int
+	seq     uint64
+	handler InterceptorHandler
+}
+
+// 预先构建的拦截器链, 注册或删除拦截器时重新构建
+type interceptorChain struct {
+	interceptors []*namedInterceptor
+	handler      Handler // 拦截器链的入口, 没有拦截器时为nil
+}
+
+var (
+	interceptorMu        sync.Mutex
+	interceptorSeq       uint64
+	customInterceptorSeq uint64
+	interceptors         = make(map[string]*namedInterceptor)
+	currentChain         atomic.Value // *interceptorChain
+)
+
+func init() {
+	RegisterInterceptor(InterceptorOptimisticLock, OrderOptimisticLock, optimisticLockInterceptor)
+	RegisterInterceptor(InterceptorLogicDelete, OrderLogicDelete, logicDeleteInterceptor)
+	RegisterInterceptor(InterceptorCache, OrderCache, cacheInterceptor)
+	RegisterInterceptor(InterceptorContext, OrderContext, contextInterceptor)
+}
+
+// RegisterInterceptor 注册拦截器, 名称相同时替换之前注册的拦截器, handler为nil时删除该拦截器
+func RegisterInterceptor(name string, order int, handler InterceptorHandler) {
+	if handler == nil {
+		RemoveInterceptor(name)
+		return
+	}
+
+	interceptorMu.Lock()
+	defer interceptorMu.Unlock()
+	if old, ok := interceptors[name]; ok {
+		interceptors[name] = &namedInterceptor{name: name, order: order, seq: old.seq, handler: handler}
+	} else {
+		interceptorSeq++
+		interceptors[name] = &namedInterceptor{name: name, order: order, seq: interceptorSeq, handler: handler}
+	}
+	rebuildInterceptorChain()
+}
+
+// RemoveInterceptor 删除拦截器, 包括内置拦截器
+func RemoveInterceptor(name string) {
+	interceptorMu.Lock()
+	defer interceptorMu.Unlock()
+	if _, ok := interceptors[name]; !ok {
+		return
+	}
+	delete(interceptors, name)
+	rebuildInterceptorChain()
+}
+
+// Interceptors 按照执行顺序返回已注册的拦截器名称
+func Interceptors() []string {
+	chain := loadInterceptorChain()
+	names := make([]string, len(chain.interceptors))
+	for i, interceptor := range chain.interceptors {
+		names[i] = interceptor.name
+	}
+
+	return names
+}
+
+func rebuildInterceptorChain() {
+	list := make([]*namedInterceptor, 0, len(interceptors))
+	for _, interceptor := range interceptors {
+		list = append(list, interceptor)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].order != list[j].order {
+			return list[i].order < list[j].order
+		}
+		return list[i].seq < list[j].seq
+	})
+
+	currentChain.Store(&interceptorChain{
+		interceptors: list,
+		handler:      buildChain(list),
+	})
+}
+
+func loadInterceptorChain() *interceptorChain {
+	chain, _ := currentChain.Load().(*interceptorChain)
+	if chain == nil {
+		return &interceptorChain{}
+	}
+
+	return chain
+}
+
+// 将拦截器链接为一个Handler, 最后一个拦截器调用option中的执行处理器
+func buildChain(list []*namedInterceptor) Handler {
+	if len(list) == 0 {
+		return nil
+	}
+
+	next := Handler(invokeExecHandler)
+	for i := len(list) - 1; i >= 0; i-- {
+		next = chainHandler(list[i].handler, next)
+	}
+
+	return next
+}
+
+func chainHandler(handler InterceptorHandler, next Handler) Handler {
+	return func(option *ExecOption) (any, error) {
+		return handler(option, next)
+	}
+}
+
+func invokeExecHandler(option *ExecOption) (any, error) {
+	return option.execHandler(option)
+}
+
+// SkipInterceptors 本次执行跳过指定名称的拦截器
+func SkipInterceptors(names ...string) Option {
+	return func(o *ExecOption) {
+		o.skipInterceptors = append(o.skipInterceptors, names...)
+	}
+}
+
+// OnlyInterceptors 本次执行只使用指定名称的拦截器, 不传入名称时不使用任何拦截器
+func OnlyInterceptors(names ...string) Option {
+	return func(o *ExecOption) {
+		o.onlyInterceptors = append([]string{}, names...)
+	}
+}
+
+// 获取本次执行使用的拦截器链, 没有指定跳过的拦截器时使用预先构建的拦截器链
+func (e *ExecOption) interceptorChain() Handler {
+	chain := loadInterceptorChain()
+	if len(e.skipInterceptors) == 0 && e.onlyInterceptors == nil {
+		return chain.handler
+	}
+
+	list := make([]*namedInterceptor, 0, len(chain.interceptors))
+	for _, interceptor := range chain.interceptors {
+		if e.interceptorEnabled(interceptor.name) {
+			list = append(list, interceptor)
+		}
+	}
+
+	return buildChain(list)
+}
+
+func (e *ExecOption) interceptorEnabled(name string) bool {
+	for _, n := range e.skipInterceptors {
+		if n == name {
+			return false
+		}
+	}
+	if e.onlyInterceptors == nil {
+		return true
+	}
+	for _, n := range e.onlyInterceptors {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 获取本次执行可以使用的拦截器
+func (e *ExecOption) lookupInterceptor(name string) InterceptorHandler {
+	if !e.interceptorEnabled(name) {
+		return nil
+	}
+
+	for _, interceptor := range loadInterceptorChain().interceptors {
+		if interceptor.name == name {
+			return interceptor.handler
+		}
+	}
+
+	return nil
+}
+
+// 执行context中的缓存拦截器
+func cacheInterceptor(option *ExecOption, next Handler) (any, error) {
+	handler := getCacheInterceptor(option.Ctx)
+	if handler == nil {
+		return next(option)
+	}
+
+	return handler(option, next)
+}
+
+// 执行通过WithInterceptors传入的拦截器
+func contextInterceptor(option *ExecOption, next Handler) (any, error) {
+	handlers := contextInterceptors(option.Ctx)
+	if len(handlers) == 0 {
+		return next(option)
+	}
+
+	for i := len(handlers) - 1; i >= 0; i-- {
+		next = chainHandler(handlers[i], next)
+	}
+
+	return next(option)
+}
+
+func contextInterceptors(ctx context.Context) []InterceptorHandler {
+	if ctx == nil {
+		return nil
+	}
+
+	switch value := ctx.Value(interceptorKey{}).(type) {
+	case InterceptorHandler:
+		return []InterceptorHandler{value}
+	case []InterceptorHandler:
+		return value
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+package vulcan
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInterceptorRegistry(t *testing.T) {
+	var calls []string
+	record := func(name string) InterceptorHandler {
+		return func(option *ExecOption, next Handler) (any, error) {
+			calls = append(calls, name)
+			return next(option)
+		}
+	}
+	RegisterInterceptor("a", 10, record("a"))
+	RegisterInterceptor("b", -10, record("b"))
+	RegisterInterceptor("c", 10, record("c"))
+	defer func() {
+		RemoveInterceptor("a")
+		RemoveInterceptor("c")
+	}()
+
+	expectedNames := []string{InterceptorOptimisticLock, InterceptorLogicDelete, InterceptorCache, "b", "a", "c", InterceptorContext}
+	if got := Interceptors(); !reflect.DeepEqual(got, expectedNames) {
+		t.Fatalf("expected %v, got %v", expectedNames, got)
+	}
+
+	db, _ := openFakeDB(t)
+	invoke := func(opts ...Option) []string {
+		calls = nil
+		option := (&ExecOption{SqlStmt: "UPDATE t_user SET age = 1", Execer: db}).Apply(opts...)
+		res, err := Invoke(option, func() (int, error) {
+			calls = append(calls, "exec")
+			return 1, nil
+		})
+		if err != nil || res != 1 {
+			t.Fatalf("unexpected result %v, %v", res, err)
+		}
+		return calls
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{"all", nil, []string{"b", "a", "c", "exec"}},
+		{"context", []Option{WithInterceptors(record("ctx"))}, []string{"b", "a", "c", "ctx", "exec"}},
+		{"skip", []Option{SkipInterceptors("a")}, []string{"b", "c", "exec"}},
+		{"only", []Option{OnlyInterceptors("c")}, []string{"c", "exec"}},
+		{"none", []Option{OnlyInterceptors()}, []string{"exec"}},
+	}
+	for _, tt := range tests {
+		if got := invoke(tt.opts...); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+
+	// 重新注册时替换拦截器并调整顺序
+	RegisterInterceptor("a", 20, record("a2"))
+	RemoveInterceptor("b")
+	if got := invoke(); !reflect.DeepEqual(got, []string{"c", "a2", "exec"}) {
+		t.Fatalf("unexpected calls %v", got)
+	}
+}
+
+func TestInvokeResultType(t *testing.T) {
+	db, _ := openFakeDB(t)
+	invoke := func(res any) (int, error) {
+		option := (&ExecOption{SqlStmt: "SELECT 1", Execer: db}).Apply(WithInterceptors(func(option *ExecOption, next Handler) (any, error) {
+			return res, nil
+		}))
+		return Invoke(option, func() (int, error) {
+			return 1, nil
+		})
+	}
+
+	if v, err := invoke(nil); err != nil || v != 0 {
+		t.Fatalf("unexpected result %v, %v", v, err)
+	}
+	// 拦截器返回的结果类型不匹配时返回错误, 而不是零值
+	v, err := invoke("1")
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) || v != 0 {
+		t.Fatalf("expected QueryError, got %v, %v", v, err)
+	}
+}
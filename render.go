@@ -0,0 +1,149 @@
+package vulcan
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 渲染sql时时间的格式, 包含时区
+const renderTimeLayout = "2006-01-02 15:04:05.999999-07:00"
+
+var (
+	mysqlStringEscaper = strings.NewReplacer(`\`, `\\`, `'`, `''`, "\x00", `\0`, "\n", `\n`, "\r", `\r`, "\x1a", `\Z`)
+	ansiStringEscaper  = strings.NewReplacer(`'`, `''`)
+)
+
+// RenderSQL 将参数按照方言转义后替换sql中的?占位符, 生成可以直接执行的sql语句, 仅用于调试
+// 字符串常量和引号引用的标识符中的?不会被替换, dialect为nil时使用DefaultDialect
+func RenderSQL(dialect Dialect, query string, args []any) string {
+	if dialect == nil {
+		dialect = DefaultDialect
+	}
+	mysql := dialect.Name() == MySQL.Name()
+
+	var (
+		builder strings.Builder
+		quote   byte
+		n       int
+	)
+	builder.Grow(len(query) + len(args)*8)
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			builder.WriteByte(c)
+			if c == '\\' && mysql && quote == '\'' && i+1 < len(query) {
+				i++
+				builder.WriteByte(query[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			builder.WriteByte(c)
+		case c == '?' && n < len(args):
+			builder.WriteString(renderValue(dialect, args[n]))
+			n++
+		default:
+			builder.WriteByte(c)
+		}
+	}
+
+	return builder.String()
+}
+
+// 将参数转换为sql常量
+func renderValue(dialect Dialect, arg any) string {
+	switch v := arg.(type) {
+	case nil:
+		return "NULL"
+	case driver.Valuer:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return "NULL"
+		}
+		value, err := v.Value()
+		if err != nil {
+			return renderString(dialect, fmt.Sprintf("!ERROR(%v)", err))
+		}
+		return renderValue(dialect, value)
+	case string:
+		return renderString(dialect, v)
+	case []byte:
+		if v == nil {
+			return "NULL"
+		}
+		return renderBytes(dialect, v)
+	case time.Time:
+		return "'" + v.Format(renderTimeLayout) + "'"
+	case bool:
+		return renderBool(dialect, v)
+	}
+
+	// 自定义类型按照底层类型处理
+
+	rv := reflect.ValueOf(arg)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return "NULL"
+		}
+		return renderValue(dialect, rv.Elem().Interface())
+	case reflect.Bool:
+		return renderBool(dialect, rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64)
+	case reflect.String:
+		return renderString(dialect, rv.String())
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			if rv.IsNil() {
+				return "NULL"
+			}
+			return renderBytes(dialect, rv.Bytes())
+		}
+	}
+
+	return renderString(dialect, fmt.Sprint(arg))
+}
+
+func renderString(dialect Dialect, s string) string {
+	if dialect.Name() == MySQL.Name() {
+		return "'" + mysqlStringEscaper.Replace(s) + "'"
+	}
+
+	return "'" + ansiStringEscaper.Replace(s) + "'"
+}
+
+func renderBytes(dialect Dialect, b []byte) string {
+	if dialect.Name() == Postgres.Name() {
+		return `'\x` + hex.EncodeToString(b) + "'::bytea"
+	}
+
+	return "X'" + hex.EncodeToString(b) + "'"
+}
+
+func renderBool(dialect Dialect, b bool) string {
+	if dialect.Name() == SQLite.Name() {
+		if b {
+			return "1"
+		}
+		return "0"
+	}
+
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
@@ -0,0 +1,100 @@
+package vulcan
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (r *recordingLogger) Debug(format string, args ...any) {
+	r.lines = append(r.lines, fmt.Sprintf(format, args...))
+}
+
+type userID int64
+
+func TestRenderSQL(t *testing.T) {
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 123000000, time.FixedZone("CST", 8*3600))
+	var nilTime *time.Time
+	age := 18
+
+	tests := []struct {
+		dialect  Dialect
+		query    string
+		args     []any
+		expected string
+	}{
+		{
+			dialect:  MySQL,
+			query:    "SELECT * FROM t_user WHERE name = ? AND age = ? AND id = ?",
+			args:     []any{`it's \ ok`, &age, userID(7)},
+			expected: `SELECT * FROM t_user WHERE name = 'it''s \\ ok' AND age = 18 AND id = 7`,
+		},
+		{
+			dialect:  Postgres,
+			query:    "SELECT * FROM t_user WHERE name = ? AND avatar = ?",
+			args:     []any{`it's \ ok`, []byte{0xca, 0xfe}},
+			expected: `SELECT * FROM t_user WHERE name = 'it''s \ ok' AND avatar = '\xcafe'::bytea`,
+		},
+		{
+			dialect:  SQLite,
+			query:    "UPDATE t_user SET avatar = ?, deleted = ? WHERE id = ?",
+			args:     []any{[]byte{0x01}, true, 3},
+			expected: "UPDATE t_user SET avatar = X'01', deleted = 1 WHERE id = 3",
+		},
+		{
+			dialect:  MySQL,
+			query:    "UPDATE t_user SET update_time = ?, delete_time = ?, nickname = ?, score = ? WHERE id = ?",
+			args:     []any{ts, nilTime, sql.NullString{}, sql.NullFloat64{Float64: 1.5, Valid: true}, nil},
+			expected: "UPDATE t_user SET update_time = '2024-05-06 07:08:09.123+08:00', delete_time = NULL, nickname = NULL, score = 1.5 WHERE id = NULL",
+		},
+		{
+			dialect:  MySQL,
+			query:    "SELECT '?', `a?` FROM t WHERE x = '\\'?' AND y = ? AND z = ?",
+			args:     []any{false},
+			expected: "SELECT '?', `a?` FROM t WHERE x = '\\'?' AND y = FALSE AND z = ?",
+		},
+	}
+	for _, tt := range tests {
+		if got := RenderSQL(tt.dialect, tt.query, tt.args); got != tt.expected {
+			t.Errorf("RenderSQL(%s, %q)\n got: %s\nwant: %s", tt.dialect.Name(), tt.query, got, tt.expected)
+		}
+	}
+}
+
+func TestSqlDebugInterceptor(t *testing.T) {
+	db, state := openFakeDB(t)
+	state.affectedFunc = func(query string) int64 {
+		return 2
+	}
+
+	var nilTime *time.Time
+	logger := &recordingLogger{}
+	err := execStmt("UPDATE t_user SET name = ?, delete_time = ?", withExecer(db), WithDialect(MySQL), func(o *ExecOption) {
+		o.Args = []any{"mango", nilTime}
+	}, WithInterceptors(SqlDebugInterceptor(logger, &SqlDebugOptions{Render: true})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.lines) != 2 || logger.lines[0] != "SQL        ==> UPDATE t_user SET name = 'mango', delete_time = NULL" ||
+		!strings.HasPrefix(logger.lines[1], "ROWS       <== 2, elapsed: ") {
+		t.Errorf("unexpected output: %q", logger.lines)
+	}
+
+	// 默认输出sql和参数两行, nil指针不会panic
+	logger.lines = nil
+	err = execStmt("UPDATE t_user SET delete_time = ?", withExecer(db), func(o *ExecOption) {
+		o.Args = []any{nilTime}
+	}, WithInterceptors(SqlDebugInterceptor(logger, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.lines) != 3 || logger.lines[1] != "PARAMETERS ==> DATETIME(NULL)" {
+		t.Errorf("unexpected output: %q", logger.lines)
+	}
+}
@@ -0,0 +1,142 @@
+package vulcan
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// RetryPolicy 事务重试策略, 零值字段使用默认值
+type RetryPolicy struct {
+	MaxAttempts    int                          // 最大执行次数, 包含第一次执行, 默认为3
+	InitialBackoff time.Duration                // 第一次重试前的等待时间, 默认为10ms
+	MaxBackoff     time.Duration                // 最大等待时间, 默认为1s
+	Multiplier     float64                      // 每次重试等待时间的增长倍数, 默认为2
+	Jitter         float64                      // 等待时间的随机抖动比例, 取值[0, 1], 默认为0.2
+	Retryable      func(err error) bool         // 判断错误是否可以重试, 默认为IsRetryableError
+	OnAttempt      func(attempt int, err error) // 每次执行结束后调用, attempt从1开始, 执行成功时err为nil
+}
+
+// DefaultRetryPolicy 默认的重试策略
+var DefaultRetryPolicy = &RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 10 * time.Millisecond,
+	MaxBackoff:     time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+func (p *RetryPolicy) withDefaults() RetryPolicy {
+	res := *DefaultRetryPolicy
+	if p == nil {
+		return res
+	}
+
+	if p.MaxAttempts > 0 {
+		res.MaxAttempts = p.MaxAttempts
+	}
+	if p.InitialBackoff > 0 {
+		res.InitialBackoff = p.InitialBackoff
+	}
+	if p.MaxBackoff > 0 {
+		res.MaxBackoff = p.MaxBackoff
+	}
+	if p.Multiplier >= 1 {
+		res.Multiplier = p.Multiplier
+	}
+	if p.Jitter > 0 && p.Jitter <= 1 {
+		res.Jitter = p.Jitter
+	}
+	res.Retryable = p.Retryable
+	res.OnAttempt = p.OnAttempt
+
+	return res
+}
+
+// 计算第attempt次执行失败后的等待时间
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		backoff *= p.Multiplier
+		if backoff >= float64(p.MaxBackoff) {
+			backoff = float64(p.MaxBackoff)
+			break
+		}
+	}
+
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (rand.Float64()*2 - 1)
+	}
+
+	return time.Duration(backoff)
+}
+
+// IsRetryableError 判断错误是否为可以重试的死锁或锁等待超时错误
+// context超时、语句执行超时等其它ErrTimeout分类的错误不会重试
+func IsRetryableError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	return errors.Is(ClassifyError(nil, err), ErrDeadlock) || isLockWaitTimeout(err)
+}
+
+// 判断是否为锁等待超时, ErrTimeout还包含了语句执行超时, 需要根据驱动的错误码区分
+func isLockWaitTimeout(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrLockWaitTimeout
+	}
+	var pgErr sqlStateError
+	if errors.As(err, &pgErr) {
+		return pgErr.SQLState() == pgErrLockNotAvailable
+	}
+
+	return false
+}
+
+// TransactionalWithRetry 执行事务, 发生死锁或锁等待超时时重新执行整个回调函数
+// 加入外层事务执行时不会重试, 由外层事务决定是否重试
+func TransactionalWithRetry(policy *RetryPolicy, fn func(opts ...Option) error, opts ...Option) error {
+	return transactionalWithRetry("", policy, fn, opts)
+}
+
+// TransactionalOnWithRetry 在指定的数据源上执行事务, 失败时按照重试策略重试
+func TransactionalOnWithRetry(name string, policy *RetryPolicy, fn func(opts ...Option) error, opts ...Option) error {
+	return transactionalWithRetry(name, policy, fn, opts)
+}
+
+func transactionalWithRetry(name string, policy *RetryPolicy, fn func(opts ...Option) error, opts []Option) error {
+	p := policy.withDefaults()
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryableError
+	}
+
+	option := (&ExecOption{}).Apply(opts...)
+	if currentTransaction(option) != nil && option.Propagation != PropagationRequiresNew {
+		p.MaxAttempts = 1
+	}
+
+	ctx := option.Context()
+	for attempt := 1; ; attempt++ {
+		err := transactional(name, fn, opts)
+		if p.OnAttempt != nil {
+			p.OnAttempt(attempt, err)
+		}
+		if err == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package vulcan
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/blastrain/vitess-sqlparser/sqlparser"
+)
+
+// 改写sql时添加的参数的占位符名称, 与解析器生成的:v1, :v2...区分
+const injectedArgName = ":vulcan_arg"
+
+// 缓存的改写结果数量上限, 超过后不再缓存
+const maxRewriteCache = 4096
+
+// sql改写的结果, 与参数的值无关, 可以缓存
+type rewrittenSql struct {
+	sql  string
+	args []int // 改写后每个参数对应的原始参数位置, -1表示改写时添加的参数
+}
+
+// 使用改写后的sql和参数替换option中的sql和参数, value为改写时添加的参数的值
+func (r *rewrittenSql) apply(option *ExecOption, value any) {
+	args := make([]any, len(r.args))
+	var sensitive []int
+	for i, idx := range r.args {
+		if idx < 0 {
+			args[i] = value
+			continue
+		}
+		if idx < len(option.Args) {
+			args[i] = option.Args[idx]
+		}
+		if option.IsSensitiveArg(idx) {
+			sensitive = append(sensitive, i)
+		}
+	}
+
+	// 参数位置发生了变化, 敏感参数的位置统一记录在sensitiveArgs中
+	if option.Meta != nil && len(option.Meta.Sensitive) > 0 {
+		meta := *option.Meta
+		meta.Sensitive = nil
+		option.Meta = &meta
+	}
+	option.sensitiveArgs = sensitive
+	option.SqlStmt = r.sql
+	option.Args = args
+}
+
+// 改写结果的缓存, 不需要改写时缓存nil
+type rewriteCache struct {
+	m    sync.Map
+	size int64
+}
+
+func (c *rewriteCache) load(key string) (*rewrittenSql, bool) {
+	v, ok := c.m.Load(key)
+	if !ok {
+		return nil, false
+	}
+
+	return v.(*rewrittenSql), true
+}
+
+func (c *rewriteCache) store(key string, res *rewrittenSql) {
+	if atomic.AddInt64(&c.size, 1) <= maxRewriteCache {
+		c.m.Store(key, res)
+	}
+}
+
+// 解析后需要改写的sql
+type parsedSql struct {
+	stmt      sqlparser.Statement
+	returning string              // RETURNING子句不能被解析, 在改写后拼接
+	quoted    map[string]struct{} // 使用双引号引用的标识符, 输出时保留引号
+}
+
+func parseRewriteSql(dialect Dialect, query string) (*parsedSql, error) {
+	parsed := &parsedSql{}
+	query, parsed.returning = splitReturning(query)
+	if dialect.Name() != MySQL.Name() {
+		query, parsed.quoted = backquoteIdents(query)
+	}
+	stmt, err := sqlparser.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	parsed.stmt = stmt
+
+	return parsed, nil
+}
+
+// 输出改写后的sql
+func formatRewrittenSql(dialect Dialect, parsed *parsedSql) *rewrittenSql {
+	res := &rewrittenSql{}
+	buf := sqlparser.NewTrackedBuffer(func(buf *sqlparser.TrackedBuffer, node sqlparser.SQLNode) {
+		formatRewrittenNode(buf, node, dialect, parsed.quoted, res)
+	})
+	buf.Myprintf("%v", parsed.stmt)
+	res.sql = buf.String() + parsed.returning
+
+	return res
+}
+
+// 改写时添加的参数
+func injectedArg() *sqlparser.SQLVal {
+	return sqlparser.NewValArg([]byte(injectedArgName))
+}
+
+// 为sql中的表添加过滤条件, 主表的条件添加到WHERE子句中, JOIN的表的条件添加到ON子句中, 子查询中的表同样会添加
+type tableFilter struct {
+	match     func(name sqlparser.TableName) bool                // 表是否需要添加过滤条件
+	condition func(qualifier sqlparser.TableName) sqlparser.Expr // 过滤条件, qualifier为表名或者别名
+}
+
+func (f *tableFilter) rewriteSelect(stmt sqlparser.SelectStatement) (bool, error) {
+	switch stmt := stmt.(type) {
+	case *sqlparser.Select:
+		changed, err := f.rewriteTables(stmt.From, func(expr sqlparser.Expr) {
+			stmt.Where = andWhere(stmt.Where, expr)
+		})
+		if err != nil {
+			return false, err
+		}
+		return f.rewriteSubqueries(changed, stmt.SelectExprs, stmt.Where, stmt.GroupBy, stmt.Having, stmt.OrderBy)
+	case *sqlparser.Union:
+		left, err := f.rewriteSelect(stmt.Left)
+		if err != nil {
+			return false, err
+		}
+		right, err := f.rewriteSelect(stmt.Right)
+		return left || right, err
+	case *sqlparser.ParenSelect:
+		return f.rewriteSelect(stmt.Select)
+	}
+
+	return false, nil
+}
+
+func (f *tableFilter) rewriteTables(exprs sqlparser.TableExprs, where func(expr sqlparser.Expr)) (bool, error) {
+	changed := false
+	for _, expr := range exprs {
+		c, err := f.rewriteTable(expr, where)
+		if err != nil {
+			return false, err
+		}
+		changed = changed || c
+	}
+
+	return changed, nil
+}
+
+func (f *tableFilter) rewriteTable(expr sqlparser.TableExpr, where func(expr sqlparser.Expr)) (bool, error) {
+	switch expr := expr.(type) {
+	case *sqlparser.AliasedTableExpr:
+		switch table := expr.Expr.(type) {
+		case sqlparser.TableName:
+			if !f.match(table) {
+				return false, nil
+			}
+			qualifier := table
+			if !expr.As.IsEmpty() {
+				qualifier = sqlparser.TableName{Name: expr.As}
+			}
+			where(f.condition(qualifier))
+			return true, nil
+		case *sqlparser.Subquery:
+			return f.rewriteSelect(table.Select)
+		}
+	case *sqlparser.ParenTableExpr:
+		return f.rewriteTables(expr.Exprs, where)
+	case *sqlparser.JoinTableExpr:
+		on := func(cond sqlparser.Expr) {
+			expr.On = andExpr(expr.On, cond)
+		}
+		// RIGHT JOIN时左边的表在ON子句中过滤, 其他JOIN右边的表在ON子句中过滤
+		leftWhere, rightWhere := where, on
+		if expr.Join == sqlparser.RightJoinStr || expr.Join == sqlparser.NaturalRightJoinStr {
+			leftWhere, rightWhere = on, where
+		}
+		left, err := f.rewriteTable(expr.LeftExpr, leftWhere)
+		if err != nil {
+			return false, err
+		}
+		right, err := f.rewriteTable(expr.RightExpr, rightWhere)
+		if err != nil {
+			return false, err
+		}
+		if expr.On != nil {
+			return f.rewriteSubqueries(left || right, expr.On)
+		}
+		return left || right, nil
+	}
+
+	return false, nil
+}
+
+// 改写表达式中的子查询
+func (f *tableFilter) rewriteSubqueries(changed bool, nodes ...sqlparser.SQLNode) (bool, error) {
+	err := sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		subquery, ok := node.(*sqlparser.Subquery)
+		if !ok {
+			return true, nil
+		}
+		c, err := f.rewriteSelect(subquery.Select)
+		changed = changed || c
+		// 子查询已经改写, 不再遍历子查询内部
+		return false, err
+	}, nodes...)
+
+	return changed, err
+}
+
+func andWhere(where *sqlparser.Where, expr sqlparser.Expr) *sqlparser.Where {
+	if where == nil {
+		return sqlparser.NewWhere(sqlparser.WhereStr, expr)
+	}
+	where.Expr = andExpr(where.Expr, expr)
+
+	return where
+}
+
+// 使用AND连接两个条件, 原条件中包含OR时需要加上括号
+func andExpr(left, right sqlparser.Expr) sqlparser.Expr {
+	if left == nil {
+		return right
+	}
+	if _, ok := left.(*sqlparser.OrExpr); ok {
+		left = &sqlparser.ParenExpr{Expr: left}
+	}
+
+	return &sqlparser.AndExpr{Left: left, Right: right}
+}
+
+// 输出改写后的sql, 占位符统一输出为?并记录参数的顺序
+func formatRewrittenNode(buf *sqlparser.TrackedBuffer, node sqlparser.SQLNode, dialect Dialect, quoted map[string]struct{}, res *rewrittenSql) {
+	mysql := dialect.Name() == MySQL.Name()
+	switch node := node.(type) {
+	case *sqlparser.SQLVal:
+		switch {
+		case node.Type == sqlparser.ValArg:
+			name := string(node.Val)
+			idx := -1
+			if name != injectedArgName {
+				// 解析器按照?出现的顺序生成:v1, :v2...
+				n, _ := strconv.Atoi(strings.TrimPrefix(name, ":v"))
+				idx = n - 1
+			}
+			res.args = append(res.args, idx)
+			buf.WriteString("?")
+			return
+		case node.Type == sqlparser.StrVal && !mysql:
+			buf.WriteString(renderString(dialect, string(node.Val)))
+			return
+		}
+	case *sqlparser.Limit:
+		// LIMIT n OFFSET m在各个方言中都可以使用
+		if node == nil {
+			return
+		}
+		buf.Myprintf(" limit %v", node.Rowcount)
+		if node.Offset != nil {
+			buf.Myprintf(" offset %v", node.Offset)
+		}
+		return
+	case sqlparser.ColIdent:
+		if !mysql {
+			writeQuotedIdent(buf, node, node.String(), dialect, quoted)
+			return
+		}
+	case sqlparser.TableIdent:
+		if !mysql {
+			writeQuotedIdent(buf, node, node.String(), dialect, quoted)
+			return
+		}
+	}
+
+	node.Format(buf)
+}
+
+// 需要引用的标识符以及原sql中引用的标识符使用方言的引用方式
+func writeQuotedIdent(buf *sqlparser.TrackedBuffer, node sqlparser.SQLNode, name string, dialect Dialect, quoted map[string]struct{}) {
+	_, ok := quoted[name]
+	if s := sqlparser.String(node); ok || strings.HasPrefix(s, "`") {
+		buf.WriteString(dialect.Quote(name))
+		return
+	}
+	buf.WriteString(name)
+}
+
+// 分离sql末尾的RETURNING子句
+func splitReturning(query string) (string, string) {
+	idx := strings.LastIndex(strings.ToUpper(query), " RETURNING ")
+	if idx < 0 || strings.ContainsAny(query[idx:], "'\"`)") {
+		return query, ""
+	}
+
+	return query[:idx], query[idx:]
+}
+
+// 解析器只支持反引号引用的标识符, 将双引号引用的标识符转换为反引号, 输出时再使用方言的引用方式
+func backquoteIdents(query string) (string, map[string]struct{}) {
+	if !strings.Contains(query, `"`) {
+		return query, nil
+	}
+
+	b := []byte(query)
+	quoted := make(map[string]struct{})
+	inString := false
+	start := -1
+	for i, c := range b {
+		switch {
+		case c == '\'' && start < 0:
+			inString = !inString
+		case c == '"' && !inString:
+			b[i] = '`'
+			if start < 0 {
+				start = i + 1
+			} else {
+				quoted[query[start:i]] = struct{}{}
+				start = -1
+			}
+		}
+	}
+
+	return string(b), quoted
+}
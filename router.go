@@ -0,0 +1,216 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Balancer 从库的负载均衡策略
+type Balancer interface {
+	// Pick 从n个从库中选择一个, 返回从库的下标
+	Pick(n int) int
+	// Observe 记录sql在下标为idx的从库上的执行耗时
+	Observe(idx int, elapsed time.Duration)
+}
+
+// RoundRobinBalancer 轮询选择从库
+type RoundRobinBalancer struct {
+	next uint64
+}
+
+func (b *RoundRobinBalancer) Pick(n int) int {
+	return int((atomic.AddUint64(&b.next, 1) - 1) % uint64(n))
+}
+
+func (b *RoundRobinBalancer) Observe(int, time.Duration) {}
+
+// LeastLatencyBalancer 选择平均耗时最小的从库, 平均耗时使用指数加权移动平均计算
+type LeastLatencyBalancer struct {
+	mu        sync.Mutex
+	latencies []float64
+}
+
+// 新的耗时在移动平均中所占的权重
+const latencyDecay = 0.2
+
+func (b *LeastLatencyBalancer) Pick(n int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.grow(n)
+
+	idx := 0
+	for i := 1; i < n; i++ {
+		if b.latencies[i] < b.latencies[idx] {
+			idx = i
+		}
+	}
+
+	return idx
+}
+
+func (b *LeastLatencyBalancer) Observe(idx int, elapsed time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.grow(idx + 1)
+
+	if b.latencies[idx] == 0 {
+		b.latencies[idx] = float64(elapsed)
+		return
+	}
+	b.latencies[idx] = b.latencies[idx]*(1-latencyDecay) + float64(elapsed)*latencyDecay
+}
+
+func (b *LeastLatencyBalancer) grow(n int) {
+	for len(b.latencies) < n {
+		b.latencies = append(b.latencies, 0)
+	}
+}
+
+// Router 读写分离的Execer, 查询语句发送到从库, 其它语句发送到主库
+// 以下情况查询语句也会发送到主库:
+// 1. 在事务中执行
+// 2. 使用ForcePrimary指定在主库上执行
+// 3. 使用StickyContext创建的context执行过写操作
+type Router struct {
+	primary  *sql.DB
+	replicas []*sql.DB
+	balancer Balancer
+}
+
+// NewRouter 创建读写分离的Execer, balancer为nil时使用RoundRobinBalancer
+func NewRouter(primary *sql.DB, replicas []*sql.DB, balancer Balancer) *Router {
+	if balancer == nil {
+		balancer = &RoundRobinBalancer{}
+	}
+
+	return &Router{
+		primary:  primary,
+		replicas: replicas,
+		balancer: balancer,
+	}
+}
+
+// Primary 获取主库
+func (r *Router) Primary() *sql.DB {
+	return r.primary
+}
+
+// Replicas 获取从库
+func (r *Router) Replicas() []*sql.DB {
+	return r.replicas
+}
+
+func (r *Router) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	markWritten(ctx)
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+func (r *Router) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	idx, db := r.route(ctx, query)
+	if idx < 0 {
+		return db.QueryContext(ctx, query, args...)
+	}
+
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, query, args...)
+	r.balancer.Observe(idx, time.Since(start))
+
+	return rows, err
+}
+
+func (r *Router) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	idx, db := r.route(ctx, query)
+	if idx < 0 {
+		return db.QueryRowContext(ctx, query, args...)
+	}
+
+	start := time.Now()
+	row := db.QueryRowContext(ctx, query, args...)
+	r.balancer.Observe(idx, time.Since(start))
+
+	return row
+}
+
+// 选择执行查询的数据库, 使用主库时返回的下标为-1
+func (r *Router) route(ctx context.Context, query string) (int, *sql.DB) {
+	if !isReadQuery(ctx, query) {
+		markWritten(ctx)
+		return -1, r.primary
+	}
+
+	if len(r.replicas) == 0 || usePrimary(ctx) {
+		return -1, r.primary
+	}
+
+	idx := r.balancer.Pick(len(r.replicas))
+	return idx, r.replicas[idx]
+}
+
+type statementKindKey struct{}
+
+// 使用Router执行时, 通过context传递生成代码记录的sql语句类型
+func (e *ExecOption) withStatementKind() {
+	if _, ok := e.Execer.(*Router); !ok || e.Meta == nil || e.Meta.Kind == SqlTypeUnknown {
+		return
+	}
+	e.Ctx = context.WithValue(e.Context(), statementKindKey{}, e.Meta.Kind)
+}
+
+// 判断是否为可以在从库上执行的查询语句, 加锁的查询需要在主库上执行
+// context中有sql元信息的语句类型时使用该类型判断, 否则根据sql语句判断
+func isReadQuery(ctx context.Context, query string) bool {
+	query = strings.ToUpper(strings.TrimLeft(query, " \t\r\n("))
+	if kind, ok := ctx.Value(statementKindKey{}).(SqlType); ok {
+		if kind != SqlTypeSelect {
+			return false
+		}
+	} else if !strings.HasPrefix(query, "SELECT") {
+		return false
+	}
+
+	return !strings.Contains(query, "FOR UPDATE") && !strings.Contains(query, "LOCK IN SHARE MODE") &&
+		!strings.Contains(query, "FOR SHARE")
+}
+
+type forcePrimaryKey struct{}
+
+// ForcePrimary 指定查询语句在主库上执行
+func ForcePrimary() Option {
+	return func(o *ExecOption) {
+		o.Ctx = context.WithValue(o.Context(), forcePrimaryKey{}, true)
+	}
+}
+
+type stickyKey struct{}
+
+// StickyContext 创建一个context, 使用该context执行过写操作后, 后续的查询都在主库上执行, 避免主从延迟读取到旧数据
+func StickyContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stickyKey{}, new(int32))
+}
+
+func markWritten(ctx context.Context) {
+	if written, ok := ctx.Value(stickyKey{}).(*int32); ok {
+		atomic.StoreInt32(written, 1)
+	}
+}
+
+func usePrimary(ctx context.Context) bool {
+	if force, _ := ctx.Value(forcePrimaryKey{}).(bool); force {
+		return true
+	}
+	if ctx.Value(txKey{}) != nil {
+		return true
+	}
+	written, ok := ctx.Value(stickyKey{}).(*int32)
+
+	return ok && atomic.LoadInt32(written) == 1
+}
+
+// RegisterRouter 注册一个读写分离的数据源, 事务在主库上开启
+func RegisterRouter(name string, router *Router) {
+	register(name, router.primary, router)
+}
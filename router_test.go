@@ -0,0 +1,129 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func queryStmt(sqlStmt string, opts ...Option) error {
+	option := (&ExecOption{SqlStmt: sqlStmt}).Apply(opts...)
+	_, err := Invoke(option, func() (any, error) {
+		rows, err := option.Select()
+		if err != nil {
+			return nil, err
+		}
+		return nil, rows.Close()
+	})
+
+	return err
+}
+
+func TestRouter(t *testing.T) {
+	primary, primaryState := openFakeDB(t)
+	replica1, replica1State := openFakeDB(t)
+	replica2, replica2State := openFakeDB(t)
+	RegisterRouter(DefaultDataSource, NewRouter(primary, []*sql.DB{replica1, replica2}, nil))
+	defer Deregister(DefaultDataSource)
+
+	steps := []func() error{
+		func() error { return queryStmt("SELECT 1") },
+		func() error { return queryStmt("SELECT 2") },
+		func() error { return execStmt("UPDATE t_user SET age = 1") },
+		func() error { return queryStmt("SELECT id FROM t_user FOR UPDATE") },
+		func() error { return queryStmt("SELECT 3", ForcePrimary()) },
+		func() error {
+			return Transactional(func(opts ...Option) error {
+				return queryStmt("SELECT 4", opts...)
+			})
+		},
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []string{"UPDATE t_user SET age = 1", "SELECT id FROM t_user FOR UPDATE", "SELECT 3", "BEGIN", "SELECT 4", "COMMIT"}
+	if got := primaryState.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v on primary, got %v", expected, got)
+	}
+	if got := replica1State.Logs(); !reflect.DeepEqual(got, []string{"SELECT 1"}) {
+		t.Fatalf("unexpected statements on replica1: %v", got)
+	}
+	if got := replica2State.Logs(); !reflect.DeepEqual(got, []string{"SELECT 2"}) {
+		t.Fatalf("unexpected statements on replica2: %v", got)
+	}
+}
+
+func TestRouterStatementKind(t *testing.T) {
+	primary, primaryState := openFakeDB(t)
+	replica, replicaState := openFakeDB(t)
+	RegisterRouter(DefaultDataSource, NewRouter(primary, []*sql.DB{replica}, nil))
+	defer Deregister(DefaultDataSource)
+
+	withKind := func(kind SqlType) Option {
+		return func(o *ExecOption) {
+			o.Meta = &StatementMeta{Kind: kind}
+		}
+	}
+	// 生成代码记录了语句类型时不再根据sql语句判断
+	steps := []func() error{
+		func() error {
+			return queryStmt("WITH t AS (SELECT id FROM t_user) SELECT id FROM t", withKind(SqlTypeSelect))
+		},
+		func() error {
+			return queryStmt("INSERT INTO t_user (age) VALUES (1) RETURNING id", withKind(SqlTypeInsert))
+		},
+		func() error { return queryStmt("SELECT id FROM t_user FOR UPDATE", withKind(SqlTypeSelect)) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []string{"INSERT INTO t_user (age) VALUES (1) RETURNING id", "SELECT id FROM t_user FOR UPDATE"}
+	if got := primaryState.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v on primary, got %v", expected, got)
+	}
+	if got, expected := replicaState.Logs(), []string{"WITH t AS (SELECT id FROM t_user) SELECT id FROM t"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v on replica, got %v", expected, got)
+	}
+}
+
+func TestRouterStickyContext(t *testing.T) {
+	primary, primaryState := openFakeDB(t)
+	replica, replicaState := openFakeDB(t)
+	router := NewRouter(primary, []*sql.DB{replica}, nil)
+
+	ctx := StickyContext(context.Background())
+	router.QueryRowContext(ctx, "SELECT 1")
+	router.ExecContext(ctx, "UPDATE t_user SET age = 1")
+	router.QueryRowContext(ctx, "SELECT 2")
+	// 其它context不受影响
+	router.QueryRowContext(context.Background(), "SELECT 3")
+
+	if got, expected := primaryState.Logs(), []string{"UPDATE t_user SET age = 1", "SELECT 2"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v on primary, got %v", expected, got)
+	}
+	if got, expected := replicaState.Logs(), []string{"SELECT 1", "SELECT 3"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v on replica, got %v", expected, got)
+	}
+}
+
+func TestLeastLatencyBalancer(t *testing.T) {
+	b := &LeastLatencyBalancer{}
+	b.Observe(0, 10*time.Millisecond)
+	b.Observe(1, time.Millisecond)
+	if idx := b.Pick(3); idx != 2 {
+		t.Fatalf("expected unobserved replica 2, got %d", idx)
+	}
+
+	b.Observe(2, 5*time.Millisecond)
+	if idx := b.Pick(3); idx != 1 {
+		t.Fatalf("expected replica 1, got %d", idx)
+	}
+}
@@ -0,0 +1,62 @@
+package vulcan
+
+// MaskedValue 日志、慢查询等记录中敏感参数替换后的值
+const MaskedValue = "******"
+
+// 通过Sensitive标记的敏感参数
+type sensitiveArg struct {
+	value any
+}
+
+// Sensitive 标记敏感参数, 生成的动态sql代码使用该函数包装db标签中带有sensitive的字段
+// 执行sql前会还原为原始值, 并记录参数的位置
+func Sensitive(value any) any {
+	return sensitiveArg{value: value}
+}
+
+// 还原通过Sensitive标记的参数
+func (e *ExecOption) resolveSensitiveArgs() {
+	for i, arg := range e.Args {
+		if s, ok := arg.(sensitiveArg); ok {
+			e.Args[i] = s.value
+			e.sensitiveArgs = append(e.sensitiveArgs, i)
+		}
+	}
+}
+
+// IsSensitiveArg 第i个参数是否为敏感参数
+func (e *ExecOption) IsSensitiveArg(i int) bool {
+	for _, idx := range e.sensitiveArgs {
+		if idx == i {
+			return true
+		}
+	}
+	if e.Meta != nil {
+		for _, idx := range e.Meta.Sensitive {
+			if idx == i {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// MaskedArgs 返回将敏感参数替换为MaskedValue后的参数, 拦截器记录参数时使用
+// 没有敏感参数时返回Args本身, 调用者不能修改返回的切片
+func (e *ExecOption) MaskedArgs() []any {
+	if len(e.sensitiveArgs) == 0 && (e.Meta == nil || len(e.Meta.Sensitive) == 0) {
+		return e.Args
+	}
+
+	args := make([]any, len(e.Args))
+	for i, arg := range e.Args {
+		if e.IsSensitiveArg(i) {
+			args[i] = MaskedValue
+		} else {
+			args[i] = arg
+		}
+	}
+
+	return args
+}
@@ -0,0 +1,69 @@
+package vulcan
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSensitiveArgs(t *testing.T) {
+	db, state := openFakeDB(t)
+	var executed []driver.Value
+	state.rowsFunc = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		executed = args
+		return nil, nil
+	}
+
+	logger := &recordingLogger{}
+	queries := make(chan *SlowQuery, 1)
+	interceptors := WithInterceptors(
+		SqlDebugInterceptor(logger, &SqlDebugOptions{Render: true}),
+		SlowQueryInterceptor(SlowQueryOptions{Logger: func(query *SlowQuery) {
+			queries <- query
+		}}),
+	)
+
+	// 动态sql通过Sensitive标记, 静态sql通过元信息中的位置标记
+	builder := NewSqlBuilder(64, 0, 0)
+	builder.AppendStmt("SELECT * FROM t_user WHERE username = ? AND password = ? AND email = ?", "mango", Sensitive("secret"), "a@b.c")
+	err := queryStmt(builder.String(), withExecer(db), interceptors, func(o *ExecOption) {
+		o.Args = builder.Args()
+		o.Meta = &StatementMeta{Sensitive: []int{2}}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(executed, []driver.Value{"mango", "secret", "a@b.c"}) {
+		t.Errorf("executed args = %v", executed)
+	}
+	expected := "SQL        ==> SELECT * FROM t_user WHERE username = 'mango' AND password = '******' AND email = '******'"
+	if len(logger.lines) == 0 || logger.lines[0] != expected {
+		t.Errorf("debug output = %q", logger.lines)
+	}
+	query := <-queries
+	if !reflect.DeepEqual(query.Args, []any{"mango", MaskedValue, MaskedValue}) {
+		t.Errorf("slow query args = %v", query.Args)
+	}
+	if strings.Contains(query.String(), "secret") {
+		t.Errorf("slow query leaks sensitive value: %s", query)
+	}
+}
+
+func TestMaskedArgs(t *testing.T) {
+	option := &ExecOption{Args: []any{1, 2}}
+	option.resolveSensitiveArgs()
+	if args := option.MaskedArgs(); &args[0] != &option.Args[0] {
+		t.Error("MaskedArgs should return Args when there are no sensitive args")
+	}
+
+	option = &ExecOption{Args: []any{1, Sensitive(2)}}
+	option.resolveSensitiveArgs()
+	if !reflect.DeepEqual(option.Args, []any{1, 2}) || !reflect.DeepEqual(option.MaskedArgs(), []any{1, MaskedValue}) {
+		t.Errorf("args = %v, masked = %v", option.Args, option.MaskedArgs())
+	}
+	if option.IsSensitiveArg(0) || !option.IsSensitiveArg(1) {
+		t.Error("unexpected sensitive args")
+	}
+}
@@ -0,0 +1,216 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SlowQuery 慢查询信息
+type SlowQuery struct {
+	SQL      string
+	Args     []any          // 敏感参数已替换为MaskedValue
+	Meta     *StatementMeta // 生成代码时记录的元信息, 手写的sql为nil
+	Caller   string         // 调用vulcan的位置, 格式为file:line
+	Duration time.Duration
+	Err      error            // sql执行的错误
+	Plan     []map[string]any // EXPLAIN的结果, 每一行为列名到值的映射
+	PlanErr  error            // 执行EXPLAIN的错误
+}
+
+func (q *SlowQuery) String() string {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("slow query %s", q.Duration))
+	if method := q.Meta.FullMethod(); method != "" {
+		builder.WriteString(" method=" + method)
+	}
+	if q.Caller != "" {
+		builder.WriteString(" caller=" + q.Caller)
+	}
+	builder.WriteString(fmt.Sprintf(" sql=%q args=%v", q.SQL, q.Args))
+	if q.Err != nil {
+		builder.WriteString(fmt.Sprintf(" error=%q", q.Err.Error()))
+	}
+	if q.Plan != nil {
+		builder.WriteString(fmt.Sprintf(" plan=%v", q.Plan))
+	}
+	if q.PlanErr != nil {
+		builder.WriteString(fmt.Sprintf(" planError=%q", q.PlanErr.Error()))
+	}
+
+	return builder.String()
+}
+
+// SlowQueryOptions 慢查询选项
+type SlowQueryOptions struct {
+	Threshold      time.Duration          // 超过该耗时的sql为慢查询
+	Explain        bool                   // 是否对慢查询的SELECT语句执行EXPLAIN, 在另外的连接上异步执行
+	ExplainTimeout time.Duration          // 执行EXPLAIN的超时时间, 默认为1s
+	SampleRate     float64                // 慢查询的采样率, 取值(0, 1], 默认为1
+	RateLimit      int                    // 每秒最多记录的慢查询数量, 0表示不限制
+	Logger         func(query *SlowQuery) // 记录慢查询, 开启Explain时在执行EXPLAIN之后调用
+}
+
+// SlowQueryInterceptor 创建慢查询拦截器
+func SlowQueryInterceptor(options SlowQueryOptions) InterceptorHandler {
+	if options.ExplainTimeout <= 0 {
+		options.ExplainTimeout = time.Second
+	}
+	if options.SampleRate <= 0 || options.SampleRate > 1 {
+		options.SampleRate = 1
+	}
+	limiter := &slowQueryLimiter{limit: options.RateLimit}
+
+	return func(option *ExecOption, next Handler) (any, error) {
+		start := time.Now()
+		resp, err := next(option)
+		used := time.Since(start)
+		if used < options.Threshold || options.Logger == nil {
+			return resp, err
+		}
+		if options.SampleRate < 1 && rand.Float64() >= options.SampleRate {
+			return resp, err
+		}
+		if !limiter.allow(time.Now()) {
+			return resp, err
+		}
+
+		query := &SlowQuery{
+			SQL:      option.SqlStmt,
+			Args:     option.MaskedArgs(),
+			Meta:     option.Meta,
+			Caller:   callerOutsideVulcan(),
+			Duration: used,
+			Err:      err,
+		}
+		if !options.Explain || option.sqlType() != SqlTypeSelect {
+			options.Logger(query)
+			return resp, err
+		}
+
+		db := explainDB(option)
+		if db == nil {
+			options.Logger(query)
+			return resp, err
+		}
+		// EXPLAIN使用原始参数
+		dialect, explainQuery, explainArgs := option.Dialect, option.query(), option.Args
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), options.ExplainTimeout)
+			defer cancel()
+			query.Plan, query.PlanErr = explain(ctx, db, dialect, explainQuery, explainArgs)
+			options.Logger(query)
+		}()
+
+		return resp, err
+	}
+}
+
+// SetupSlowQueryInterceptor 注册慢查询拦截器
+func SetupSlowQueryInterceptor(options SlowQueryOptions) {
+	RegisterInterceptor(InterceptorSlowQuery, OrderSlowQuery, SlowQueryInterceptor(options))
+}
+
+// 每秒最多允许limit个慢查询
+type slowQueryLimiter struct {
+	limit int
+
+	mu     sync.Mutex
+	window time.Time
+	count  int
+}
+
+func (l *slowQueryLimiter) allow(now time.Time) bool {
+	if l.limit <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.window) >= time.Second {
+		l.window = now
+		l.count = 0
+	}
+	if l.count >= l.limit {
+		return false
+	}
+	l.count++
+
+	return true
+}
+
+// 获取执行EXPLAIN的数据库, 在事务中执行时使用事务所属的数据源, 不占用事务的连接
+func explainDB(option *ExecOption) *sql.DB {
+	if db, ok := option.Execer.(*sql.DB); ok {
+		return db
+	}
+	if ds := findDataSource(option.DataSource, option.Execer); ds != nil {
+		return ds.db
+	}
+
+	return nil
+}
+
+func explain(ctx context.Context, db *sql.DB, dialect Dialect, query string, args []any) ([]map[string]any, error) {
+	prefix := "EXPLAIN "
+	if dialect != nil && dialect.Name() == SQLite.Name() {
+		prefix = "EXPLAIN QUERY PLAN "
+	}
+
+	rows, err := db.QueryContext(ctx, prefix+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	plan := make([]map[string]any, 0)
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]any, len(columns))
+		for i, c := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[c] = string(b)
+			} else {
+				row[c] = values[i]
+			}
+		}
+		plan = append(plan, row)
+	}
+
+	return plan, rows.Err()
+}
+
+const vulcanPackagePrefix = "github.com/mangohow/vulcan."
+
+// 获取第一个不在vulcan包中的调用位置, 一般为生成的mapper方法
+func callerOutsideVulcan() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, vulcanPackagePrefix) && !strings.HasPrefix(frame.Function, "runtime.") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package vulcan
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowQueryInterceptor(t *testing.T) {
+	db, state := openFakeDB(t)
+	state.rowsFunc = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if strings.HasPrefix(query, "EXPLAIN ") {
+			return []string{"table", "type"}, [][]driver.Value{{[]byte("t_user"), "ALL"}}
+		}
+		return nil, nil
+	}
+
+	queries := make(chan *SlowQuery, 4)
+	interceptor := SlowQueryInterceptor(SlowQueryOptions{
+		Explain: true,
+		Logger: func(query *SlowQuery) {
+			queries <- query
+		},
+	})
+
+	meta := &StatementMeta{Mapper: "UserMapper", Method: "FindByName", Kind: SqlTypeSelect, Table: "t_user"}
+	err := queryStmt("SELECT * FROM t_user WHERE name = ?", WithInterceptors(interceptor), func(o *ExecOption) {
+		o.Execer, o.Args, o.Meta = db, []any{"mango"}, meta
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var query *SlowQuery
+	select {
+	case query = <-queries:
+	case <-time.After(time.Second):
+		t.Fatal("slow query not logged")
+	}
+	if query.Meta != meta || len(query.Args) != 1 || query.Args[0] != "mango" {
+		t.Errorf("unexpected slow query: %v", query)
+	}
+	// 测试代码与vulcan在同一个包中, 调用位置为testing包
+	if query.Caller == "" || strings.Contains(query.Caller, "slowquery.go") {
+		t.Errorf("caller = %q", query.Caller)
+	}
+	if query.PlanErr != nil || len(query.Plan) != 1 || query.Plan[0]["table"] != "t_user" || query.Plan[0]["type"] != "ALL" {
+		t.Errorf("plan = %v, err = %v", query.Plan, query.PlanErr)
+	}
+	if logs := state.Logs(); logs[len(logs)-1] != "EXPLAIN SELECT * FROM t_user WHERE name = ?" {
+		t.Errorf("logs = %v", logs)
+	}
+
+	// 非SELECT语句不执行EXPLAIN, 执行失败的sql也会被记录
+	state.Reset()
+	state.errFunc = func(query string) error {
+		return errors.New("boom")
+	}
+	if err := execStmt("UPDATE t_user SET age = 1", WithInterceptors(interceptor), withExecer(db)); err == nil {
+		t.Fatal("expected error")
+	}
+	query = <-queries
+	if query.Err == nil || query.Plan != nil {
+		t.Errorf("unexpected slow query: %v", query)
+	}
+	for _, l := range state.Logs() {
+		if strings.HasPrefix(l, "EXPLAIN") {
+			t.Errorf("unexpected explain: %v", l)
+		}
+	}
+}
+
+func TestSlowQueryRateLimit(t *testing.T) {
+	db, _ := openFakeDB(t)
+	count := 0
+	interceptor := SlowQueryInterceptor(SlowQueryOptions{
+		RateLimit: 2,
+		Logger: func(query *SlowQuery) {
+			count++
+		},
+	})
+	for i := 0; i < 5; i++ {
+		if err := execStmt("UPDATE t_user SET age = 1", WithInterceptors(interceptor), withExecer(db)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if count != 2 {
+		t.Errorf("logged %d slow queries, want 2", count)
+	}
+
+	limiter := &slowQueryLimiter{limit: 1}
+	now := time.Now()
+	if !limiter.allow(now) || limiter.allow(now) || !limiter.allow(now.Add(time.Second)) {
+		t.Error("limiter should reset after one second")
+	}
+}
+
+func withExecer(execer Execer) Option {
+	return func(o *ExecOption) {
+		o.Execer = execer
+	}
+}
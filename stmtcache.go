@@ -0,0 +1,316 @@
+package vulcan
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// ErrStmtCacheClosed 预编译语句缓存已关闭
+var ErrStmtCacheClosed = errors.New("statement cache is closed")
+
+// StmtCacheStats 预编译语句缓存的统计信息
+type StmtCacheStats struct {
+	Size      int    // 当前缓存的语句数量
+	Capacity  int    // 缓存容量
+	Hits      uint64 // 命中次数
+	Misses    uint64 // 未命中次数, 即预编译的次数
+	Evictions uint64 // 淘汰的语句数量
+
+	TxSize      int    // 进行中的事务缓存的语句数量
+	TxHits      uint64 // 事务中命中已转换语句的次数
+	TxEvictions uint64 // 事务中淘汰的语句数量
+}
+
+// StmtCache 以sql为key的预编译语句LRU缓存, 只缓存静态sql
+type StmtCache struct {
+	db       *sql.DB
+	capacity int
+
+	mu     sync.Mutex
+	ll     *list.List
+	items  map[string]*list.Element
+	stats  StmtCacheStats
+	txSize int
+	closed bool
+}
+
+type stmtEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int  // 正在使用该语句的数量
+	evicted bool // 已经被淘汰, 使用结束后关闭
+}
+
+// NewStmtCache 创建预编译语句缓存, capacity小于等于0时默认为128
+func NewStmtCache(db *sql.DB, capacity int) *StmtCache {
+	if capacity <= 0 {
+		capacity = 128
+	}
+
+	return &StmtCache{
+		db:       db,
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// 获取预编译语句, 使用结束后需要调用release
+func (c *StmtCache) acquire(ctx context.Context, query string) (*stmtEntry, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrStmtCacheClosed
+	}
+	if elem, ok := c.items[query]; ok {
+		c.ll.MoveToFront(elem)
+		entry := elem.Value.(*stmtEntry)
+		entry.refs++
+		c.stats.Hits++
+		c.mu.Unlock()
+		return entry, nil
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		stmt.Close()
+		return nil, ErrStmtCacheClosed
+	}
+	// 其它goroutine已经预编译了该语句
+	if elem, ok := c.items[query]; ok {
+		stmt.Close()
+		c.ll.MoveToFront(elem)
+		entry := elem.Value.(*stmtEntry)
+		entry.refs++
+		return entry, nil
+	}
+
+	entry := &stmtEntry{query: query, stmt: stmt, refs: 1}
+	c.items[query] = c.ll.PushFront(entry)
+	for c.ll.Len() > c.capacity {
+		c.evictLocked(c.ll.Back())
+	}
+
+	return entry, nil
+}
+
+func (c *StmtCache) release(entry *stmtEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+func (c *StmtCache) evictLocked(elem *list.Element) {
+	entry := c.ll.Remove(elem).(*stmtEntry)
+	delete(c.items, entry.query)
+	entry.evicted = true
+	c.stats.Evictions++
+	if entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// Stats 获取缓存的统计信息
+func (c *StmtCache) Stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = c.ll.Len()
+	stats.Capacity = c.capacity
+	stats.TxSize = c.txSize
+
+	return stats
+}
+
+// Close 关闭所有缓存的预编译语句, 正在使用的语句在使用结束后关闭
+func (c *StmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	var err error
+	for _, elem := range c.items {
+		entry := elem.Value.(*stmtEntry)
+		entry.evicted = true
+		if entry.refs == 0 {
+			if e := entry.stmt.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	c.items = make(map[string]*list.Element)
+	c.ll.Init()
+
+	return err
+}
+
+var stmtCaches sync.Map // *sql.DB -> *StmtCache
+
+// EnableStmtCache 为db开启预编译语句缓存, 在该db以及该db开启的事务上执行的静态sql会使用预编译语句
+// 重复开启时返回已经存在的缓存
+func EnableStmtCache(db *sql.DB, capacity int) *StmtCache {
+	cache, _ := stmtCaches.LoadOrStore(db, NewStmtCache(db, capacity))
+	return cache.(*StmtCache)
+}
+
+// DisableStmtCache 关闭db的预编译语句缓存
+func DisableStmtCache(db *sql.DB) error {
+	cache, ok := stmtCaches.LoadAndDelete(db)
+	if !ok {
+		return nil
+	}
+
+	return cache.(*StmtCache).Close()
+}
+
+// GetStmtCache 获取db的预编译语句缓存
+func GetStmtCache(db *sql.DB) (*StmtCache, bool) {
+	cache, ok := stmtCaches.Load(db)
+	if !ok {
+		return nil, false
+	}
+
+	return cache.(*StmtCache), true
+}
+
+// 获取执行sql使用的预编译语句, 不能使用预编译语句时返回nil
+// 在事务中执行时, 将db的预编译语句转换为事务的预编译语句, 并缓存在事务中, 事务结束时由database/sql关闭
+// 通过WithTransaction传入的事务无法确定所属的db, 不使用预编译语句
+func (e *ExecOption) preparedStmt() (*sql.Stmt, func()) {
+	if !e.Static {
+		return nil, nil
+	}
+
+	var (
+		db  *sql.DB
+		txc *txContext
+	)
+	switch execer := e.Execer.(type) {
+	case *sql.DB:
+		db = execer
+	case *sql.Tx:
+		txc, _ = e.Context().Value(txKey{}).(*txContext)
+		if txc == nil || txc.tx != execer {
+			return nil, nil
+		}
+		ds, err := getDataSource(txc.dataSource)
+		if err != nil {
+			return nil, nil
+		}
+		db = ds.db
+	default:
+		return nil, nil
+	}
+
+	cache, ok := GetStmtCache(db)
+	if !ok {
+		return nil, nil
+	}
+
+	query := e.query()
+	if txc != nil {
+		if stmt := txc.getStmt(query); stmt != nil {
+			return stmt, func() {}
+		}
+	}
+
+	entry, err := cache.acquire(e.Context(), query)
+	if err != nil {
+		return nil, nil
+	}
+	if txc == nil {
+		return entry.stmt, func() {
+			cache.release(entry)
+		}
+	}
+
+	stmt := txc.tx.StmtContext(e.Context(), entry.stmt)
+	cache.release(entry)
+	txc.putStmt(cache, query, stmt)
+
+	return stmt, func() {}
+}
+
+// 事务中转换的预编译语句LRU缓存, 容量与db的缓存相同
+type txStmtCache struct {
+	cache *StmtCache
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type txStmtEntry struct {
+	query string
+	stmt  *sql.Stmt
+}
+
+func newTxStmtCache(cache *StmtCache) *txStmtCache {
+	return &txStmtCache{
+		cache: cache,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// 统计信息记录在db的缓存中
+func (c *txStmtCache) get(query string) *sql.Stmt {
+	elem, ok := c.items[query]
+	if !ok {
+		return nil
+	}
+	c.ll.MoveToFront(elem)
+	c.cache.mu.Lock()
+	c.cache.stats.TxHits++
+	c.cache.mu.Unlock()
+
+	return elem.Value.(*txStmtEntry).stmt
+}
+
+func (c *txStmtCache) put(query string, stmt *sql.Stmt) {
+	// 其它goroutine已经转换了该语句, 替换的语句在事务结束时关闭
+	if elem, ok := c.items[query]; ok {
+		elem.Value = &txStmtEntry{query: query, stmt: stmt}
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	c.items[query] = c.ll.PushFront(&txStmtEntry{query: query, stmt: stmt})
+	evictions := 0
+	for c.ll.Len() > c.cache.capacity {
+		// 事务的连接同一时间只能执行一条语句, 淘汰的语句不会正在使用
+		entry := c.ll.Remove(c.ll.Back()).(*txStmtEntry)
+		delete(c.items, entry.query)
+		entry.stmt.Close()
+		evictions++
+	}
+
+	c.cache.mu.Lock()
+	c.cache.txSize += 1 - evictions
+	c.cache.stats.TxEvictions += uint64(evictions)
+	c.cache.mu.Unlock()
+}
+
+// 事务结束时调用, 语句由database/sql关闭, 只需要更新统计信息
+func (c *txStmtCache) close() {
+	c.cache.mu.Lock()
+	c.cache.txSize -= c.ll.Len()
+	c.cache.mu.Unlock()
+	c.items = nil
+	c.ll.Init()
+}
@@ -0,0 +1,155 @@
+package vulcan
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func execStatic(db Execer, sqlStmt string, opts ...Option) error {
+	option := (&ExecOption{SqlStmt: sqlStmt, Args: []any{1}, Execer: db, Static: true}).Apply(opts...)
+	_, err := Invoke(option, func() (sql.Result, error) {
+		return option.Exec()
+	})
+
+	return err
+}
+
+func countPrepares(logs []string) int {
+	n := 0
+	for _, l := range logs {
+		if strings.HasPrefix(l, "PREPARE ") {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestStmtCache(t *testing.T) {
+	db, state := openFakeDB(t)
+	cache := EnableStmtCache(db, 2)
+	defer DisableStmtCache(db)
+
+	for _, q := range []string{"q1", "q1", "q2", "q1", "q3"} {
+		if err := execStatic(db, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 非静态sql不使用缓存
+	option := &ExecOption{SqlStmt: "q4", Args: []any{1}, Execer: db}
+	if _, err := option.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := cache.Stats()
+	expectedStats := StmtCacheStats{Size: 2, Capacity: 2, Hits: 2, Misses: 3, Evictions: 1}
+	if stats != expectedStats {
+		t.Fatalf("expected stats %+v, got %+v", expectedStats, stats)
+	}
+
+	expected := []string{"PREPARE q1", "q1", "q1", "PREPARE q2", "q2", "q1", "PREPARE q3", "CLOSE q2", "q3", "q4"}
+	if got := state.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	state.Reset()
+	if err := DisableStmtCache(db); err != nil {
+		t.Fatal(err)
+	}
+	if got := state.Logs(); len(got) != 2 {
+		t.Fatalf("expected cached statements to be closed, got %v", got)
+	}
+	if err := execStatic(db, "q1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStmtCacheInTransaction(t *testing.T) {
+	state := setupTxTest(t)
+	db, _ := DataSource(DefaultDataSource)
+	cache := EnableStmtCache(db, 0)
+	defer DisableStmtCache(db)
+
+	err := Transactional(func(opts ...Option) error {
+		for i := 0; i < 3; i++ {
+			if err := execStatic(nil, "UPDATE t_user SET age = ?", opts...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 事务中只需要转换一次预编译语句
+	if stats := cache.Stats(); stats.Misses != 1 || stats.Hits != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	expected := []string{"BEGIN", "UPDATE t_user SET age = ?", "UPDATE t_user SET age = ?", "UPDATE t_user SET age = ?", "COMMIT"}
+	if got := statements(state.Logs()); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestStmtCacheInTransactionEviction(t *testing.T) {
+	state := setupTxTest(t)
+	db, _ := DataSource(DefaultDataSource)
+	cache := EnableStmtCache(db, 2)
+	defer DisableStmtCache(db)
+
+	// 事务中缓存的语句数量不超过db缓存的容量
+	err := Transactional(func(opts ...Option) error {
+		for _, q := range []string{"q1", "q2", "q1", "q3", "q2", "q1"} {
+			if err := execStatic(nil, q, opts...); err != nil {
+				return err
+			}
+		}
+		stats := cache.Stats()
+		if stats.TxSize != 2 || stats.TxHits != 1 || stats.TxEvictions != 3 {
+			t.Errorf("unexpected stats in transaction %+v", stats)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := cache.Stats(); stats.TxSize != 0 {
+		t.Fatalf("expected no statements held after transaction, got %+v", stats)
+	}
+	expected := []string{"BEGIN", "q1", "q2", "q1", "q3", "q2", "q1", "COMMIT"}
+	if got := statements(state.Logs()); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func benchmarkStaticExec(b *testing.B, cached bool) {
+	db, state := openFakeDB(b)
+	state.prepareOnly = true
+	state.prepareCost = 50 * time.Microsecond
+	if cached {
+		EnableStmtCache(db, 0)
+		defer DisableStmtCache(db)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := execStatic(db, "SELECT id FROM t_user WHERE id = ?"); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(countPrepares(state.Logs()))/float64(b.N), "prepares/op")
+}
+
+func BenchmarkExecWithoutStmtCache(b *testing.B) {
+	benchmarkStaticExec(b, false)
+}
+
+func BenchmarkExecWithStmtCache(b *testing.B) {
+	benchmarkStaticExec(b, true)
+}
@@ -0,0 +1,235 @@
+package vulcan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/blastrain/vitess-sqlparser/sqlparser"
+)
+
+var (
+	// ErrNoTenant 开启多租户拦截器后, 执行sql时context中没有租户
+	ErrNoTenant = errors.New("vulcan: tenant not found in context")
+	// ErrTenantColumnAssigned sql中指定了租户列的值, 可能写入其它租户的数据
+	ErrTenantColumnAssigned = errors.New("vulcan: tenant column must not be assigned")
+)
+
+// DefaultTenantColumn 默认的租户列名
+const DefaultTenantColumn = "tenant_id"
+
+type tenantKey struct{}
+
+// WithTenant 返回包含租户的context, 多租户拦截器从context中获取租户
+func WithTenant(ctx context.Context, tenant any) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext 获取context中的租户
+func TenantFromContext(ctx context.Context) (any, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tenant := ctx.Value(tenantKey{})
+
+	return tenant, tenant != nil
+}
+
+// IgnoreTenant 本次执行不进行多租户改写, 用于跨租户的管理操作
+func IgnoreTenant() Option {
+	return SkipInterceptors(InterceptorTenant)
+}
+
+// TenantOptions 多租户选项
+type TenantOptions struct {
+	Column       string                                // 租户列名, 默认为DefaultTenantColumn
+	IgnoreTables []string                              // 不需要租户隔离的全局表
+	Tenant       func(ctx context.Context) (any, bool) // 获取租户, 默认为TenantFromContext
+}
+
+// TenantInterceptor 创建多租户拦截器
+// 在SELECT、UPDATE、DELETE语句的WHERE子句中添加租户条件, 在INSERT语句中添加租户列, JOIN的表在ON子句中添加租户条件
+// 子查询同样会被改写, context中没有租户或者sql无法解析时返回错误
+// INSERT和UPDATE语句不能指定租户列的值, 跨租户写入需要使用IgnoreTenant
+// 改写后的sql使用CacheableCtx、CacheEvictCtx缓存时, 缓存key中会加入租户
+func TenantInterceptor(options TenantOptions) InterceptorHandler {
+	if options.Column == "" {
+		options.Column = DefaultTenantColumn
+	}
+	if options.Tenant == nil {
+		options.Tenant = TenantFromContext
+	}
+	rewriter := newTenantRewriter(options.Column, options.IgnoreTables)
+
+	return func(option *ExecOption, next Handler) (any, error) {
+		dialect := option.Dialect
+		if dialect == nil {
+			dialect = DefaultDialect
+		}
+		rewrite, err := rewriter.rewrite(dialect, option.SqlStmt)
+		if err != nil {
+			return nil, err
+		}
+		if rewrite == nil {
+			return next(option)
+		}
+
+		tenant, ok := options.Tenant(option.Context())
+		if !ok {
+			return nil, ErrNoTenant
+		}
+		rewrite.apply(option, tenant)
+		option.tenant = tenant
+
+		return next(option)
+	}
+}
+
+// SetupTenantInterceptor 注册多租户拦截器
+func SetupTenantInterceptor(options TenantOptions) {
+	RegisterInterceptor(InterceptorTenant, OrderTenant, TenantInterceptor(options))
+}
+
+type tenantRewriter struct {
+	tableFilter
+	column string
+	ignore map[string]struct{}
+
+	cache rewriteCache
+}
+
+func newTenantRewriter(column string, ignoreTables []string) *tenantRewriter {
+	t := &tenantRewriter{
+		column: column,
+		ignore: make(map[string]struct{}, len(ignoreTables)),
+	}
+	for _, table := range ignoreTables {
+		t.ignore[strings.ToLower(table)] = struct{}{}
+	}
+	t.tableFilter = tableFilter{
+		match: func(name sqlparser.TableName) bool {
+			return !t.ignored(name)
+		},
+		condition: t.condition,
+	}
+
+	return t
+}
+
+// 改写sql, 不需要改写时返回nil
+func (t *tenantRewriter) rewrite(dialect Dialect, query string) (*rewrittenSql, error) {
+	key := dialect.Name() + ":" + query
+	if res, ok := t.cache.load(key); ok {
+		return res, nil
+	}
+
+	res, err := t.doRewrite(dialect, query)
+	if err != nil {
+		return nil, fmt.Errorf("vulcan: rewrite sql for tenant failed, use IgnoreTenant or IgnoreTables to skip it: %w", err)
+	}
+	t.cache.store(key, res)
+
+	return res, nil
+}
+
+func (t *tenantRewriter) doRewrite(dialect Dialect, query string) (*rewrittenSql, error) {
+	parsed, err := parseRewriteSql(dialect, query)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	switch stmt := parsed.stmt.(type) {
+	case sqlparser.SelectStatement:
+		changed, err = t.rewriteSelect(stmt)
+	case *sqlparser.Update:
+		changed, err = t.rewriteTables(stmt.TableExprs, func(expr sqlparser.Expr) {
+			stmt.Where = andWhere(stmt.Where, expr)
+		})
+		if err == nil && changed {
+			err = t.checkAssignments(stmt.Exprs)
+		}
+		if err == nil {
+			changed, err = t.rewriteSubqueries(changed, stmt.Exprs, stmt.Where)
+		}
+	case *sqlparser.Delete:
+		changed, err = t.rewriteTables(stmt.TableExprs, func(expr sqlparser.Expr) {
+			stmt.Where = andWhere(stmt.Where, expr)
+		})
+		if err == nil {
+			changed, err = t.rewriteSubqueries(changed, stmt.Where)
+		}
+	case *sqlparser.Insert:
+		changed, err = t.rewriteInsert(stmt)
+	}
+	if err != nil || !changed {
+		return nil, err
+	}
+
+	return formatRewrittenSql(dialect, parsed), nil
+}
+
+func (t *tenantRewriter) ignored(name sqlparser.TableName) bool {
+	if _, ok := t.ignore[strings.ToLower(name.Name.String())]; ok {
+		return true
+	}
+	if !name.Qualifier.IsEmpty() {
+		_, ok := t.ignore[strings.ToLower(name.Qualifier.String()+"."+name.Name.String())]
+		return ok
+	}
+
+	return false
+}
+
+// 租户条件, 如t_user.tenant_id = ?
+func (t *tenantRewriter) condition(qualifier sqlparser.TableName) sqlparser.Expr {
+	return &sqlparser.ComparisonExpr{
+		Operator: sqlparser.EqualStr,
+		Left:     &sqlparser.ColName{Name: sqlparser.NewColIdent(t.column), Qualifier: qualifier},
+		Right:    injectedArg(),
+	}
+}
+
+func (t *tenantRewriter) rewriteInsert(stmt *sqlparser.Insert) (bool, error) {
+	if t.ignored(stmt.Table) {
+		return false, nil
+	}
+	if stmt.Columns.FindColumn(sqlparser.NewColIdent(t.column)) >= 0 {
+		return false, ErrTenantColumnAssigned
+	}
+	if err := t.checkAssignments(sqlparser.UpdateExprs(stmt.OnDup)); err != nil {
+		return false, err
+	}
+	if len(stmt.Columns) == 0 {
+		return false, errors.New("insert statement without column list")
+	}
+
+	stmt.Columns = append(stmt.Columns, sqlparser.NewColIdent(t.column))
+	switch rows := stmt.Rows.(type) {
+	case sqlparser.Values:
+		for i := range rows {
+			rows[i] = append(rows[i], injectedArg())
+		}
+	case *sqlparser.Select:
+		rows.SelectExprs = append(rows.SelectExprs, &sqlparser.AliasedExpr{Expr: injectedArg()})
+		if _, err := t.rewriteSelect(rows); err != nil {
+			return false, err
+		}
+	default:
+		return false, fmt.Errorf("unsupported insert rows %T", rows)
+	}
+
+	return true, nil
+}
+
+// 检查SET子句是否修改了租户列
+func (t *tenantRewriter) checkAssignments(exprs sqlparser.UpdateExprs) error {
+	for _, expr := range exprs {
+		if expr.Name.Name.EqualString(t.column) {
+			return ErrTenantColumnAssigned
+		}
+	}
+
+	return nil
+}
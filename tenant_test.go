@@ -0,0 +1,241 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestTenantRewrite(t *testing.T) {
+	rewriter := newTenantRewriter(DefaultTenantColumn, []string{"T_REGION"})
+	tests := []struct {
+		dialect  Dialect
+		query    string
+		expected string
+		args     []int
+	}{
+		{
+			dialect:  MySQL,
+			query:    "SELECT * FROM t_user WHERE age > ? OR name = ? LIMIT ?, ?",
+			expected: "select * from t_user where (age > ? or name = ?) and t_user.tenant_id = ? limit ? offset ?",
+			args:     []int{0, 1, -1, 3, 2},
+		},
+		{
+			dialect:  MySQL,
+			query:    "SELECT u.id, o.no FROM t_user u LEFT JOIN t_order o ON o.user_id = u.id JOIN t_region r ON u.region_id = r.id",
+			expected: "select u.id, o.no from t_user as u left join t_order as o on o.user_id = u.id and o.tenant_id = ? join t_region as r on u.region_id = r.id where u.tenant_id = ?",
+			args:     []int{-1, -1},
+		},
+		{
+			dialect:  MySQL,
+			query:    "SELECT * FROM t_user WHERE id IN (SELECT user_id FROM t_order WHERE no = ?)",
+			expected: "select * from t_user where id in (select user_id from t_order where no = ? and t_order.tenant_id = ?) and t_user.tenant_id = ?",
+			args:     []int{0, -1, -1},
+		},
+		{
+			dialect:  MySQL,
+			query:    "INSERT INTO t_user (name, age) VALUES (?, ?), (?, ?)",
+			expected: "insert into t_user(name, age, tenant_id) values (?, ?, ?), (?, ?, ?)",
+			args:     []int{0, 1, -1, 2, 3, -1},
+		},
+		{
+			dialect:  Postgres,
+			query:    "INSERT INTO t_user (name, \"order\") VALUES (?, ?) RETURNING id",
+			expected: "insert into t_user(name, \"order\", tenant_id) values (?, ?, ?) RETURNING id",
+			args:     []int{0, 1, -1},
+		},
+		{
+			dialect:  MySQL,
+			query:    "UPDATE t_user SET name = ? WHERE id = ?",
+			expected: "update t_user set name = ? where id = ? and t_user.tenant_id = ?",
+			args:     []int{0, 1, -1},
+		},
+		{
+			dialect:  Postgres,
+			query:    "DELETE FROM t_user WHERE name = 'it''s'",
+			expected: "delete from t_user where name = 'it''s' and t_user.tenant_id = ?",
+			args:     []int{-1},
+		},
+	}
+	for _, tt := range tests {
+		res, err := rewriter.rewrite(tt.dialect, tt.query)
+		if err != nil {
+			t.Fatalf("rewrite %q: %v", tt.query, err)
+		}
+		if res.sql != tt.expected || !reflect.DeepEqual(res.args, tt.args) {
+			t.Errorf("rewrite %q\n got: %s %v\nwant: %s %v", tt.query, res.sql, res.args, tt.expected, tt.args)
+		}
+	}
+
+	// 全局表不需要改写
+	for _, query := range []string{"SELECT * FROM t_region WHERE id = ?", "INSERT INTO t_region (name, tenant_id) VALUES (?, ?)", "SET autocommit = 1"} {
+		if res, err := rewriter.rewrite(MySQL, query); err != nil || res != nil {
+			t.Errorf("rewrite %q: unexpected %v, %v", query, res, err)
+		}
+	}
+	// 不能指定租户列的值, 避免写入其它租户的数据
+	for _, query := range []string{
+		"INSERT INTO t_user (name, tenant_id) VALUES (?, ?)",
+		"INSERT INTO t_user (name) VALUES (?) ON DUPLICATE KEY UPDATE tenant_id = ?",
+		"UPDATE t_user SET name = ?, TENANT_ID = ? WHERE id = ?",
+		"UPDATE t_user u JOIN t_order o ON o.user_id = u.id SET u.tenant_id = o.tenant_id",
+	} {
+		if _, err := rewriter.rewrite(MySQL, query); !errors.Is(err, ErrTenantColumnAssigned) {
+			t.Errorf("rewrite %q: expected ErrTenantColumnAssigned, got %v", query, err)
+		}
+	}
+	if _, err := rewriter.rewrite(MySQL, "SELECT * FROM WHERE"); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestTenantInterceptor(t *testing.T) {
+	SetupTenantInterceptor(TenantOptions{})
+	defer RemoveInterceptor(InterceptorTenant)
+
+	db, state := openFakeDB(t)
+	var executed []driver.Value
+	state.rowsFunc = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		executed = args
+		return nil, nil
+	}
+
+	logger := &recordingLogger{}
+	ctx := WithTenant(context.Background(), int64(7))
+	err := queryStmt("SELECT * FROM t_user WHERE name = ? AND password = ?", withExecer(db), WithContext(ctx),
+		WithInterceptors(SqlDebugInterceptor(logger, &SqlDebugOptions{Render: true})), func(o *ExecOption) {
+			o.Args = []any{"mango", "secret"}
+			o.Meta = &StatementMeta{Sensitive: []int{1}}
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logs := state.Logs(); len(logs) != 1 || logs[0] != "select * from t_user where name = ? and password = ? and t_user.tenant_id = ?" {
+		t.Errorf("executed sql = %q", logs)
+	}
+	if !reflect.DeepEqual(executed, []driver.Value{"mango", "secret", int64(7)}) {
+		t.Errorf("executed args = %v", executed)
+	}
+	// 改写后敏感参数的位置保持正确
+	expected := "SQL        ==> select * from t_user where name = 'mango' and password = '******' and t_user.tenant_id = 7"
+	if len(logger.lines) == 0 || logger.lines[0] != expected {
+		t.Errorf("debug output = %q", logger.lines)
+	}
+
+	state.Reset()
+	err = queryStmt("SELECT * FROM t_user", withExecer(db))
+	if !errors.Is(err, ErrNoTenant) {
+		t.Errorf("expected ErrNoTenant, got %v", err)
+	}
+	if logs := state.Logs(); len(logs) != 0 {
+		t.Errorf("statement should not be executed without tenant: %q", logs)
+	}
+
+	err = queryStmt("SELECT * FROM t_user", withExecer(db), IgnoreTenant())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logs := state.Logs(); len(logs) != 1 || logs[0] != "SELECT * FROM t_user" {
+		t.Errorf("executed sql = %q", logs)
+	}
+}
+
+func TestTenantWithPagination(t *testing.T) {
+	SetupTenantInterceptor(TenantOptions{})
+	defer RemoveInterceptor(InterceptorTenant)
+	SetupPaginationInterceptor()
+	defer SetPaginationInterceptor(nil)
+
+	db, state := openFakeDB(t)
+	state.rowsFunc = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"count"}, [][]driver.Value{{int64(25)}}
+	}
+
+	paging := NewPaging(2, 10).AddAscs("id")
+	option := &ExecOption{
+		SqlStmt:   "SELECT username FROM t_user WHERE id > ?",
+		Args:      []any{1},
+		Execer:    db,
+		Ctx:       WithTenant(context.Background(), int64(7)),
+		Extension: paging,
+	}
+	_, err := Invoke(option, func() (any, error) {
+		return nil, option.Get().Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 改写后的sql仍然需要分页
+	expected := []string{
+		"select COUNT(*) from t_user where id > ? and t_user.tenant_id = ?",
+		"select username from t_user where id > ? and t_user.tenant_id = ? ORDER BY id ASC LIMIT 10 OFFSET 10",
+	}
+	if got := state.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	if paging.TotalCount() != 25 {
+		t.Fatalf("unexpected paging %+v", paging)
+	}
+}
+
+type mapCache[T any] map[string]*T
+
+func (c mapCache[T]) Get(key string) (*T, bool) {
+	val, ok := c[key]
+	return val, ok
+}
+
+func (c mapCache[T]) Set(key string, value *T) {
+	c[key] = value
+}
+
+func (c mapCache[T]) Delete(key string) {
+	delete(c, key)
+}
+
+func TestTenantCache(t *testing.T) {
+	SetupTenantInterceptor(TenantOptions{})
+	defer RemoveInterceptor(InterceptorTenant)
+
+	db, state := openFakeDB(t)
+	state.rowsFunc = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"name"}, [][]driver.Value{{fmt.Sprintf("user of tenant %v", args[len(args)-1])}}
+	}
+
+	cache := mapCache[string]{}
+	cfg := &CacheConfig[string]{Manager: cache, Key: "user:id:1"}
+	find := func(tenant int64) string {
+		option := &ExecOption{
+			SqlStmt: "SELECT name FROM t_user WHERE id = ?",
+			Args:    []any{1},
+			Execer:  db,
+			Ctx:     WithTenant(CacheableCtx(cfg), tenant),
+		}
+		name, err := Invoke(option, func() (*string, error) {
+			var name string
+			err := option.Get().Scan(&name)
+			return &name, err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *name
+	}
+
+	// 相同的参数和缓存key, 不同的租户不能读取到其它租户的缓存
+	for _, tenant := range []int64{7, 8, 7, 8} {
+		if name, expected := find(tenant), fmt.Sprintf("user of tenant %d", tenant); name != expected {
+			t.Errorf("tenant %d: expected %q, got %q", tenant, expected, name)
+		}
+	}
+	if logs := state.Logs(); len(logs) != 2 {
+		t.Errorf("expected each tenant to query once, got %q", logs)
+	}
+	if _, ok := cache["user:id:1:tenant:7"]; !ok || len(cache) != 2 {
+		t.Errorf("unexpected cache keys %v", cache)
+	}
+}
@@ -0,0 +1,83 @@
+package vulcan
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// key为SqlType, value为time.Duration
+var defaultTimeouts sync.Map
+
+// SetDefaultTimeout 设置某一类sql语句的默认超时时间, mapper方法以及模型都没有声明超时时间时使用
+// timeout小于等于0时取消该类sql语句的默认超时时间
+func SetDefaultTimeout(kind SqlType, timeout time.Duration) {
+	if timeout <= 0 {
+		defaultTimeouts.Delete(kind)
+		return
+	}
+	defaultTimeouts.Store(kind, timeout)
+}
+
+// WithTimeout 指定本次执行的超时时间, 覆盖生成代码中声明的超时时间以及默认超时时间
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *ExecOption) {
+		o.timeout = timeout
+	}
+}
+
+// 获取本次执行的超时时间, 优先级为WithTimeout、生成代码中声明的超时时间、SetDefaultTimeout设置的默认值
+func (e *ExecOption) statementTimeout() time.Duration {
+	if e.timeout > 0 {
+		return e.timeout
+	}
+	if e.Meta != nil && e.Meta.Timeout > 0 {
+		return e.Meta.Timeout
+	}
+	if timeout, ok := defaultTimeouts.Load(e.sqlType()); ok {
+		return timeout.(time.Duration)
+	}
+
+	return 0
+}
+
+// 为本次执行设置超时时间, context的截止时间更早时不修改
+func (e *ExecOption) withStatementTimeout() context.CancelFunc {
+	timeout := e.statementTimeout()
+	if timeout <= 0 {
+		return func() {}
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := e.Context().Deadline(); ok && !d.After(deadline) {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithDeadline(e.Context(), deadline)
+	e.Ctx = ctx
+	e.timeout = timeout
+	e.timeoutCtx = ctx
+	return cancel
+}
+
+// 超时时间到达导致执行失败时, 返回带有超时时间的错误, 由wrapError分类为ErrTimeout
+func (e *ExecOption) timeoutError(err error) error {
+	if e.timeoutCtx == nil || e.timeoutCtx.Err() != context.DeadlineExceeded {
+		return err
+	}
+
+	return &statementTimeoutError{timeout: e.timeout, err: err}
+}
+
+type statementTimeoutError struct {
+	timeout time.Duration
+	err     error
+}
+
+func (e *statementTimeoutError) Error() string {
+	return fmt.Sprintf("statement timeout %s exceeded: %v", e.timeout, e.err)
+}
+
+func (e *statementTimeoutError) Unwrap() error {
+	return e.err
+}
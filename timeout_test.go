@@ -0,0 +1,79 @@
+package vulcan
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatementTimeout(t *testing.T) {
+	db, _ := openFakeDB(t)
+	option := &ExecOption{
+		SqlStmt: "SELECT * FROM t_user WHERE id = ?",
+		Execer:  db,
+		Meta:    &StatementMeta{Mapper: "UserMapper", Method: "FindById", Kind: SqlTypeSelect, Timeout: 20 * time.Millisecond},
+	}
+	_, err := Invoke(option, func() (any, error) {
+		<-option.Context().Done()
+		// 驱动返回的错误不一定是context.DeadlineExceeded
+		return nil, errors.New("invalid connection")
+	})
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "UserMapper.FindById") || !strings.Contains(msg, "20ms") {
+		t.Errorf("unexpected error message: %s", msg)
+	}
+
+	// 没有超时的执行错误不分类为ErrTimeout
+	option = &ExecOption{Execer: db, Meta: &StatementMeta{Kind: SqlTypeSelect, Timeout: time.Second}}
+	_, err = Invoke(option, func() (any, error) {
+		return nil, errors.New("invalid connection")
+	})
+	if err == nil || errors.Is(err, ErrTimeout) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestStatementTimeoutPriority(t *testing.T) {
+	db, _ := openFakeDB(t)
+	SetDefaultTimeout(SqlTypeSelect, time.Minute)
+	defer SetDefaultTimeout(SqlTypeSelect, 0)
+
+	parent, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	parentDeadline, _ := parent.Deadline()
+
+	tests := []struct {
+		name   string
+		meta   *StatementMeta
+		opts   []Option
+		expect time.Duration // 0表示没有截止时间, 小于0表示使用parent的截止时间
+	}{
+		{name: "default by kind", meta: &StatementMeta{Kind: SqlTypeSelect}, expect: time.Minute},
+		{name: "no default", meta: &StatementMeta{Kind: SqlTypeUpdate}},
+		{name: "declared", meta: &StatementMeta{Kind: SqlTypeSelect, Timeout: time.Hour}, expect: time.Hour},
+		{name: "option", meta: &StatementMeta{Kind: SqlTypeSelect, Timeout: time.Hour}, opts: []Option{WithTimeout(2 * time.Hour)}, expect: 2 * time.Hour},
+		{name: "earlier context deadline", meta: &StatementMeta{Kind: SqlTypeSelect}, opts: []Option{WithContext(parent)}, expect: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			option := (&ExecOption{Execer: db, Meta: tt.meta}).Apply(tt.opts...)
+			start := time.Now()
+			_, _ = Invoke(option, func() (any, error) {
+				deadline, ok := option.Context().Deadline()
+				switch {
+				case tt.expect == 0 && ok:
+					t.Errorf("unexpected deadline %s", deadline)
+				case tt.expect < 0 && !deadline.Equal(parentDeadline):
+					t.Errorf("deadline = %s, expected %s", deadline, parentDeadline)
+				case tt.expect > 0 && (deadline.Sub(start) < tt.expect || deadline.Sub(start) > tt.expect+time.Second):
+					t.Errorf("deadline after %s, expected %s", deadline.Sub(start), tt.expect)
+				}
+				return nil, nil
+			})
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracer 链路追踪接口, 可以适配任意链路追踪系统, vulcan不依赖具体的实现
+type Tracer interface {
+	// Start 开启一个span, ctx中的span为父span, 返回包含新span的context
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span 一次sql执行对应的span
+type Span interface {
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// span中记录的属性
+const (
+	TraceAttrSystem    = "db.system"       // 数据库方言名称
+	TraceAttrOperation = "db.operation"    // sql语句的类型, 如SELECT
+	TraceAttrStatement = "db.statement"    // sql语句
+	TraceAttrTable     = "db.sql.table"    // sql操作的表名
+	TraceAttrRows      = "db.rows"         // 查询返回或者影响的行数
+	TraceAttrMethod    = "vulcan.method"   // mapper方法名称
+	TraceAttrSource    = "vulcan.source"   // mapper方法在源文件中的位置
+	TraceAttrDuration  = "vulcan.duration" // sql执行的耗时
+)
+
+// TracingOptions 链路追踪选项
+type TracingOptions struct {
+	OmitStatement bool                // 不记录sql语句
+	Sanitize      func(string) string // 记录sql语句前对sql进行处理, 如SanitizeSQL
+}
+
+// TracingInterceptor 创建链路追踪拦截器, 每次执行sql时创建一个span
+func TracingInterceptor(tracer Tracer, options *TracingOptions) InterceptorHandler {
+	if options == nil {
+		options = &TracingOptions{}
+	}
+
+	return func(option *ExecOption, next Handler) (any, error) {
+		ctx, span := tracer.Start(option.Context(), spanName(option))
+		defer span.End()
+		option.Ctx = ctx
+
+		kind := option.sqlType()
+		span.SetAttribute(TraceAttrOperation, kind.String())
+		if option.Dialect != nil {
+			span.SetAttribute(TraceAttrSystem, option.Dialect.Name())
+		}
+		if meta := option.Meta; meta != nil {
+			span.SetAttribute(TraceAttrMethod, meta.FullMethod())
+			if meta.Table != "" {
+				span.SetAttribute(TraceAttrTable, meta.Table)
+			}
+			if meta.Source != "" {
+				span.SetAttribute(TraceAttrSource, meta.Source)
+			}
+		}
+
+		start := time.Now()
+		res, err := next(option)
+		span.SetAttribute(TraceAttrDuration, time.Since(start))
+
+		// 分页拦截器会修改sql, 执行结束后再记录sql语句
+		if !options.OmitStatement {
+			stmt := option.SqlStmt
+			if options.Sanitize != nil {
+				stmt = options.Sanitize(stmt)
+			}
+			span.SetAttribute(TraceAttrStatement, stmt)
+		}
+		if err != nil {
+			span.RecordError(err)
+			return res, err
+		}
+		if rows, ok := resultRows(res); ok {
+			span.SetAttribute(TraceAttrRows, rows)
+		}
+
+		return res, nil
+	}
+}
+
+// SetupTracingInterceptor 注册链路追踪拦截器
+func SetupTracingInterceptor(tracer Tracer, options *TracingOptions) {
+	RegisterInterceptor(InterceptorTracing, OrderTracing, TracingInterceptor(tracer, options))
+}
+
+// span名称, 有元信息时使用mapper方法名称, 否则使用sql语句的类型
+func spanName(option *ExecOption) string {
+	if option.Meta != nil && option.Meta.Method != "" {
+		return "vulcan " + option.Meta.FullMethod()
+	}
+
+	return "vulcan " + option.sqlType().String()
+}
+
+// 获取执行结果的行数, 增删改返回影响的行数, 查询返回结果的数量
+func resultRows(res any) (int64, bool) {
+	if res == nil {
+		return 0, false
+	}
+	if result, ok := res.(sql.Result); ok {
+		rows, err := result.RowsAffected()
+		return rows, err == nil
+	}
+
+	v := reflect.ValueOf(res)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return int64(v.Len()), true
+	case reflect.Pointer, reflect.Map, reflect.Interface:
+		if v.IsNil() {
+			return 0, true
+		}
+	}
+
+	return 1, true
+}
+
+// SanitizeSQL 将sql中的字符串和数字常量替换为?, 避免在链路追踪中记录敏感数据
+// 双引号和反引号引用的标识符不会被替换
+func SanitizeSQL(query string) string {
+	var (
+		builder strings.Builder
+		quote   byte
+	)
+	builder.Grow(len(query))
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote == '\'':
+			if c == quote {
+				// 两个连续的引号为转义
+				if i+1 < len(query) && query[i+1] == quote {
+					i++
+					continue
+				}
+				quote = 0
+				builder.WriteByte('?')
+			}
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			builder.WriteByte(c)
+		case c == '\'':
+			quote = c
+		case c == '"' || c == '`':
+			quote = c
+			builder.WriteByte(c)
+		case isDigit(c) && (i == 0 || !isIdentChar(query[i-1])):
+			for i+1 < len(query) && (isDigit(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			builder.WriteByte('?')
+		default:
+			builder.WriteByte(c)
+		}
+	}
+
+	return builder.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// RecordingTracer 在内存中记录span的Tracer, 用于测试
+type RecordingTracer struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+// RecordedSpan RecordingTracer记录的span
+type RecordedSpan struct {
+	Name       string
+	Parent     *RecordedSpan
+	Attributes map[string]any
+	Err        error
+	StartTime  time.Time
+	EndTime    time.Time
+
+	tracer *RecordingTracer
+}
+
+type recordedSpanKey struct{}
+
+// NewRecordingTracer 创建在内存中记录span的Tracer
+func NewRecordingTracer() *RecordingTracer {
+	return &RecordingTracer{}
+}
+
+// Start 开启一个span, ctx中存在RecordingTracer创建的span时作为父span
+func (t *RecordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(recordedSpanKey{}).(*RecordedSpan)
+	span := &RecordedSpan{
+		Name:       name,
+		Parent:     parent,
+		Attributes: make(map[string]any),
+		StartTime:  time.Now(),
+		tracer:     t,
+	}
+
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+// Spans 返回已经结束的span, 按照结束顺序排列
+func (t *RecordingTracer) Spans() []*RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*RecordedSpan(nil), t.spans...)
+}
+
+// Reset 清空记录的span
+func (t *RecordingTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = nil
+}
+
+func (s *RecordedSpan) SetAttribute(key string, value any) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.Attributes[key] = value
+}
+
+func (s *RecordedSpan) RecordError(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.Err = err
+}
+
+func (s *RecordedSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.EndTime = time.Now()
+	s.tracer.spans = append(s.tracer.spans, s)
+}
+
+// Duration span的耗时
+func (s *RecordedSpan) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
@@ -0,0 +1,101 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestTracingInterceptor(t *testing.T) {
+	tracer := NewRecordingTracer()
+	SetupTracingInterceptor(tracer, &TracingOptions{Sanitize: SanitizeSQL})
+	defer RemoveInterceptor(InterceptorTracing)
+
+	db, state := openFakeDB(t)
+	state.rowsFunc = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"id"}, [][]driver.Value{{int64(1)}, {int64(2)}}
+	}
+
+	ctx, parent := tracer.Start(context.Background(), "service")
+	option := &ExecOption{
+		SqlStmt: "SELECT id FROM t_user WHERE status = 'active' AND age > ?",
+		Args:    []any{18},
+		Execer:  db,
+		Ctx:     ctx,
+		Meta:    &StatementMeta{Mapper: "UserMapper", Method: "FindActive", Kind: SqlTypeSelect, Table: "t_user"},
+	}
+	ids, err := Invoke(option, func() ([]int64, error) {
+		rows, err := option.Select()
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var res []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return nil, err
+			}
+			res = append(res, id)
+		}
+		return res, rows.Err()
+	})
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("unexpected result %v, %v", ids, err)
+	}
+
+	spans := tracer.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "vulcan UserMapper.FindActive" || span.Parent != parent {
+		t.Fatalf("unexpected span %s, parent %v", span.Name, span.Parent)
+	}
+	expected := map[string]any{
+		TraceAttrSystem:    "mysql",
+		TraceAttrOperation: "SELECT",
+		TraceAttrStatement: "SELECT id FROM t_user WHERE status = ? AND age > ?",
+		TraceAttrTable:     "t_user",
+		TraceAttrMethod:    "UserMapper.FindActive",
+		TraceAttrRows:      int64(2),
+	}
+	for k, v := range expected {
+		if span.Attributes[k] != v {
+			t.Errorf("attribute %s: expected %v, got %v", k, v, span.Attributes[k])
+		}
+	}
+
+	// 执行失败时记录错误
+	tracer.Reset()
+	errExec := errors.New("exec failed")
+	state.errFunc = func(query string) error {
+		return errExec
+	}
+	if err := execStatic(db, "DELETE FROM t_user WHERE id = ?"); !errors.Is(err, errExec) {
+		t.Fatalf("expected %v, got %v", errExec, err)
+	}
+	spans = tracer.Spans()
+	if len(spans) != 1 || spans[0].Name != "vulcan DELETE" || !errors.Is(spans[0].Err, errExec) || spans[0].Parent != nil {
+		t.Fatalf("unexpected spans %+v", spans)
+	}
+}
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT * FROM t_user WHERE id = 10", "SELECT * FROM t_user WHERE id = ?"},
+		{"SELECT * FROM t_user2 WHERE name = 'it''s' AND score > 1.5", "SELECT * FROM t_user2 WHERE name = ? AND score > ?"},
+		{"SELECT * FROM t_user WHERE id = ? LIMIT 10 OFFSET 20", "SELECT * FROM t_user WHERE id = ? LIMIT ? OFFSET ?"},
+		{`SELECT "2fa", ` + "`t1`" + ` FROM t_user WHERE id = $1`, `SELECT "2fa", ` + "`t1`" + ` FROM t_user WHERE id = $1`},
+	}
+
+	for _, tt := range tests {
+		if got := SanitizeSQL(tt.query); got != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, got)
+		}
+	}
+}
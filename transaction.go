@@ -0,0 +1,457 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Propagation 事务传播行为
+type Propagation int
+
+const (
+	// PropagationRequired 存在事务时加入该事务, 否则开启一个新事务
+	PropagationRequired Propagation = iota
+	// PropagationRequiresNew 总是开启一个新事务, 已存在的事务会被挂起
+	PropagationRequiresNew
+	// PropagationNested 存在事务时通过SAVEPOINT执行嵌套事务, 否则开启一个新事务
+	PropagationNested
+	// PropagationSupports 存在事务时加入该事务, 否则以非事务方式执行
+	PropagationSupports
+	// PropagationNever 以非事务方式执行, 存在事务时返回错误
+	PropagationNever
+)
+
+func (p Propagation) String() string {
+	switch p {
+	case PropagationRequired:
+		return "REQUIRED"
+	case PropagationRequiresNew:
+		return "REQUIRES_NEW"
+	case PropagationNested:
+		return "NESTED"
+	case PropagationSupports:
+		return "SUPPORTS"
+	case PropagationNever:
+		return "NEVER"
+	default:
+		return fmt.Sprintf("Propagation(%d)", int(p))
+	}
+}
+
+var (
+	// ErrTransactionExists 传播行为为NEVER时存在事务
+	ErrTransactionExists = errors.New("existing transaction found for propagation NEVER")
+	// ErrRollbackOnly 加入的事务执行失败, 外层事务只能回滚
+	ErrRollbackOnly = errors.New("transaction rolled back because it has been marked as rollback-only")
+	// ErrExternalTransaction 通过WithTransaction传入的事务无法确定数据源, 不能在其它连接上开启新事务
+	ErrExternalTransaction = errors.New("data source is required to start a new transaction outside of the transaction passed by WithTransaction")
+)
+
+// WithPropagation 指定Transactional的事务传播行为, 默认为PropagationRequired
+func WithPropagation(propagation Propagation) Option {
+	return func(o *ExecOption) {
+		o.Propagation = propagation
+	}
+}
+
+// TxOptions 事务选项
+type TxOptions struct {
+	Isolation sql.IsolationLevel // 隔离级别, 默认使用数据库的隔离级别
+	ReadOnly  bool               // 是否为只读事务
+	Timeout   time.Duration      // 事务超时时间, 超时后事务会被回滚
+	Label     string             // 事务标签, 用于在拦截器中区分事务
+}
+
+func (o *TxOptions) sqlTxOptions() *sql.TxOptions {
+	if o == nil {
+		return nil
+	}
+
+	return &sql.TxOptions{
+		Isolation: o.Isolation,
+		ReadOnly:  o.ReadOnly,
+	}
+}
+
+// WithTxOptions 指定开启事务时的选项, 用于StartTransaction和Transactional
+func WithTxOptions(options *TxOptions) Option {
+	return func(o *ExecOption) {
+		o.TxOptions = options
+	}
+}
+
+type txKey struct{}
+
+// 事务上下文, 通过Option和context在嵌套调用中传递
+type txContext struct {
+	tx           *sql.Tx
+	dataSource   string
+	options      *TxOptions
+	deadline     time.Time
+	savepointSeq int64
+	rollbackOnly int32
+	external     bool               // 通过WithTransaction传入的事务, 由调用者提交或回滚
+	cancel       context.CancelFunc // 释放事务超时时间的context, 事务结束时调用
+
+	stmtMu sync.Mutex
+	stmts  *txStmtCache // 事务中使用的预编译语句, 事务结束时由database/sql关闭
+
+	hooks TxHooks // 事务结束后执行的回调函数
+}
+
+// 生成传入回调函数的Option, 同时将传播行为重置为默认值, 避免影响内层调用
+func (t *txContext) option() Option {
+	return func(o *ExecOption) {
+		o.Execer = t.tx
+		o.DataSource = t.dataSource
+		o.Propagation = PropagationRequired
+		o.TxOptions = t.options
+		o.Ctx = context.WithValue(o.Context(), txKey{}, t)
+	}
+}
+
+func (t *txContext) setRollbackOnly() {
+	atomic.StoreInt32(&t.rollbackOnly, 1)
+}
+
+func (t *txContext) isRollbackOnly() bool {
+	return atomic.LoadInt32(&t.rollbackOnly) == 1
+}
+
+func (t *txContext) nextSavepoint() string {
+	return fmt.Sprintf("vulcan_sp_%d", atomic.AddInt64(&t.savepointSeq, 1))
+}
+
+func (t *txContext) getStmt(query string) *sql.Stmt {
+	t.stmtMu.Lock()
+	defer t.stmtMu.Unlock()
+	if t.stmts == nil {
+		return nil
+	}
+	return t.stmts.get(query)
+}
+
+func (t *txContext) putStmt(cache *StmtCache, query string, stmt *sql.Stmt) {
+	t.stmtMu.Lock()
+	defer t.stmtMu.Unlock()
+	if t.stmts == nil {
+		t.stmts = newTxStmtCache(cache)
+	}
+	t.stmts.put(query, stmt)
+}
+
+// 事务结束时调用
+func (t *txContext) closeStmts() {
+	t.stmtMu.Lock()
+	defer t.stmtMu.Unlock()
+	if t.stmts != nil {
+		t.stmts.close()
+		t.stmts = nil
+	}
+}
+
+// 从Option和context中查找已经存在的事务
+func currentTransaction(option *ExecOption) *txContext {
+	txc, _ := option.Context().Value(txKey{}).(*txContext)
+	tx, ok := option.Execer.(*sql.Tx)
+	if !ok {
+		return txc
+	}
+
+	// 通过WithTransaction传入的事务
+	if txc == nil || txc.tx != tx {
+		txc = &txContext{
+			tx:         tx,
+			dataSource: option.DataSource,
+			external:   true,
+		}
+	}
+
+	return txc
+}
+
+// 在sql执行时使用事务的超时时间
+func (e *ExecOption) withTxDeadline() context.CancelFunc {
+	txc, ok := e.Context().Value(txKey{}).(*txContext)
+	if !ok || txc.deadline.IsZero() || e.Execer != Execer(txc.tx) {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithDeadline(e.Context(), txc.deadline)
+	e.Ctx = ctx
+	return cancel
+}
+
+// 通过StartTransaction开启的事务, key为*sql.Tx, value为*txContext
+var startedTxs sync.Map
+
+// StartTransaction 使用该函数开启一个事务, 返回Tx对象
+// 可以通过WithDataSource指定数据源, 通过WithTxOptions指定事务选项
+// 使用CommitTransaction或RollbackTransaction结束事务, 同时释放事务超时时间的context
+// 直接调用Tx的Commit或Rollback时, context在超时后才会释放
+func StartTransaction(opts ...Option) (*sql.Tx, error) {
+	return StartTransactionOn("", opts...)
+}
+
+// StartTransactionOn 在指定的数据源上开启一个事务
+func StartTransactionOn(name string, opts ...Option) (*sql.Tx, error) {
+	option := (&ExecOption{}).Apply(opts...)
+	if name != "" {
+		option.DataSource = name
+	}
+	ds, err := getDataSource(option.DataSource)
+	if err != nil {
+		return nil, err
+	}
+
+	txc, err := beginTransaction(option.Context(), ds, option.TxOptions)
+	if err != nil {
+		return nil, err
+	}
+	// 没有超时时间时不需要释放context
+	if txc.deadline.IsZero() {
+		return txc.tx, nil
+	}
+	// 直接调用Tx的Commit或Rollback时, 超时后删除记录
+	timer := time.AfterFunc(time.Until(txc.deadline), func() {
+		releaseStartedTx(txc.tx)
+	})
+	cancel := txc.cancel
+	txc.cancel = func() {
+		timer.Stop()
+		cancel()
+	}
+	startedTxs.Store(txc.tx, txc)
+	if !time.Now().Before(txc.deadline) {
+		releaseStartedTx(txc.tx)
+	}
+
+	return txc.tx, nil
+}
+
+// CommitTransaction 提交StartTransaction开启的事务, 并释放事务超时时间的context
+func CommitTransaction(tx *sql.Tx) error {
+	defer releaseStartedTx(tx)
+	return tx.Commit()
+}
+
+// RollbackTransaction 回滚StartTransaction开启的事务, 并释放事务超时时间的context
+func RollbackTransaction(tx *sql.Tx) error {
+	defer releaseStartedTx(tx)
+	return tx.Rollback()
+}
+
+func releaseStartedTx(tx *sql.Tx) {
+	if txc, ok := startedTxs.LoadAndDelete(tx); ok {
+		txc.(*txContext).cancel()
+	}
+}
+
+// 开启事务, 设置了超时时间时, 超时后事务会被回滚
+// 事务结束后需要调用txContext的cancel释放超时时间的context
+func beginTransaction(ctx context.Context, ds *dataSource, options *TxOptions) (*txContext, error) {
+	cancel := context.CancelFunc(func() {})
+	if options != nil && options.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+	}
+
+	tx, err := ds.db.BeginTx(ctx, options.sqlTxOptions())
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	deadline, _ := ctx.Deadline()
+
+	return &txContext{
+		tx:         tx,
+		dataSource: ds.name,
+		options:    options,
+		deadline:   deadline,
+		cancel:     cancel,
+	}, nil
+}
+
+// Transactional 使用该函数来执行事务, 在回调函数中调用数据库操作语句
+// opts中可以传入外层事务的Option, 以及通过WithPropagation指定传播行为
+// 没有外层事务时, 在opts指定的数据源或默认数据源上开启事务
+// 加入通过WithTransaction传入的事务时, 事务被标记为只能回滚会返回ErrRollbackOnly, 由调用者回滚事务
+func Transactional(fn func(opts ...Option) error, opts ...Option) error {
+	return transactional("", fn, opts)
+}
+
+// TransactionalOn 在指定的数据源上执行事务
+func TransactionalOn(name string, fn func(opts ...Option) error, opts ...Option) error {
+	return transactional(name, fn, opts)
+}
+
+// TransactionalContext 执行事务, 事务保存在传入回调函数的context中
+// 使用该context执行的mapper方法会自动在事务中执行, 不需要传递Option
+func TransactionalContext(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	return transactional("", func(opts ...Option) error {
+		return fn((&ExecOption{}).Apply(opts...).Context())
+	}, append([]Option{WithContext(ctx)}, opts...))
+}
+
+func transactional(name string, fn func(opts ...Option) error, opts []Option) (err error) {
+	option := (&ExecOption{}).Apply(opts...)
+	current := currentTransaction(option)
+	if name == "" {
+		name = option.DataSource
+	}
+	// 外层事务属于其它数据源时, 不能加入该事务
+	if current != nil && current.dataSource != "" && name != "" && current.dataSource != name {
+		current = nil
+	}
+	// 通过WithTransaction传入的事务由调用者提交或回滚, 无法在提交时检查是否只能回滚
+	// 由第一层加入该事务的调用返回ErrRollbackOnly, 调用者需要回滚事务
+	if current != nil && current.external && option.Context().Value(txKey{}) != current {
+		defer func() {
+			if err == nil && current.isRollbackOnly() {
+				err = ErrRollbackOnly
+			}
+		}()
+	}
+
+	switch option.Propagation {
+	case PropagationRequired:
+		if current != nil {
+			return joinTransaction(current, fn, opts)
+		}
+		return runInNewTransaction(option, name, fn, opts)
+	case PropagationRequiresNew:
+		// 无法确定传入的事务属于哪个数据源, 不能在默认数据源上开启新事务
+		if current != nil && current.external && name == "" {
+			return ErrExternalTransaction
+		}
+		return runInNewTransaction(option, name, fn, opts)
+	case PropagationNested:
+		if current != nil {
+			return runWithSavepoint(option.Context(), current, fn, opts)
+		}
+		return runInNewTransaction(option, name, fn, opts)
+	case PropagationSupports:
+		if current != nil {
+			return joinTransaction(current, fn, opts)
+		}
+		return fn(appendOption(opts, WithPropagation(PropagationRequired))...)
+	case PropagationNever:
+		if current != nil {
+			return ErrTransactionExists
+		}
+		return fn(appendOption(opts, WithPropagation(PropagationRequired))...)
+	default:
+		return fmt.Errorf("unsupported transaction propagation %v", option.Propagation)
+	}
+}
+
+// 加入已存在的事务, 执行失败时将事务标记为只能回滚
+func joinTransaction(current *txContext, fn func(opts ...Option) error, opts []Option) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			current.setRollbackOnly()
+			panic(r)
+		}
+		if err != nil {
+			current.setRollbackOnly()
+		}
+	}()
+
+	return fn(appendOption(opts, current.option())...)
+}
+
+// 开启一个新事务执行
+func runInNewTransaction(option *ExecOption, name string, fn func(opts ...Option) error, opts []Option) (err error) {
+	ds, err := getDataSource(name)
+	if err != nil {
+		return err
+	}
+
+	current, err := beginTransaction(option.Context(), ds, option.TxOptions)
+	if err != nil {
+		return err
+	}
+	defer current.cancel()
+
+	tx := current.tx
+	defer func() {
+		var (
+			e         error
+			committed bool
+		)
+		if r := recover(); r != nil || err != nil {
+			e = tx.Rollback()
+			if e == nil && r != nil {
+				e = fmt.Errorf("recovered from %v", r)
+			}
+			// 超时后事务已经被回滚, 保留原始错误
+			if errors.Is(e, sql.ErrTxDone) && err != nil {
+				e = nil
+			}
+		} else if current.isRollbackOnly() {
+			e = tx.Rollback()
+			if e == nil {
+				e = ErrRollbackOnly
+			}
+		} else {
+			e = tx.Commit()
+			committed = e == nil
+			if errors.Is(e, sql.ErrTxDone) && !current.deadline.IsZero() && time.Now().After(current.deadline) {
+				e = context.DeadlineExceeded
+			}
+		}
+		if e != nil {
+			err = e
+		}
+		current.closeStmts()
+
+		// 事务结束后执行注册的回调函数
+		if errs := current.hooks.run(committed); len(errs) > 0 {
+			err = &TxHookError{Committed: committed, Err: err, HookErrs: errs}
+		}
+	}()
+
+	return fn(appendOption(opts, current.option())...)
+}
+
+// 使用SAVEPOINT执行嵌套事务, 执行失败时只回滚到SAVEPOINT, 不影响外层事务
+func runWithSavepoint(ctx context.Context, current *txContext, fn func(opts ...Option) error, opts []Option) (err error) {
+	savepoint := current.nextSavepoint()
+	if _, err = current.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return err
+	}
+	hookMark := current.hooks.mark()
+	defer func() {
+		r := recover()
+		if r == nil && err == nil {
+			_, err = current.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint)
+			return
+		}
+
+		// 嵌套事务中的修改已经撤销, 注册的回调函数不能按照提交执行
+		current.hooks.rollbackTo(hookMark)
+		if _, e := current.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); e != nil {
+			// 无法回滚到SAVEPOINT, 外层事务只能整体回滚
+			current.setRollbackOnly()
+			if err == nil {
+				err = e
+			}
+		}
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	return fn(appendOption(opts, current.option())...)
+}
+
+// 在opts后追加Option, 不修改调用者的切片
+func appendOption(opts []Option, opt ...Option) []Option {
+	res := make([]Option, 0, len(opts)+len(opt))
+	res = append(res, opts...)
+	return append(res, opt...)
+}
@@ -0,0 +1,517 @@
+package vulcan
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func setupTxTest(t *testing.T) *fakeDB {
+	db, state := openFakeDB(t)
+	Register(DefaultDataSource, db)
+	t.Cleanup(func() {
+		Deregister(DefaultDataSource)
+	})
+
+	return state
+}
+
+func execStmt(sqlStmt string, opts ...Option) error {
+	option := (&ExecOption{SqlStmt: sqlStmt}).Apply(opts...)
+	_, err := Invoke(option, func() (sql.Result, error) {
+		return option.Exec()
+	})
+
+	return err
+}
+
+func TestTransactionalPropagation(t *testing.T) {
+	errInner := errors.New("inner error")
+	tests := []struct {
+		name        string
+		propagation Propagation
+		innerErr    error
+		expectErr   error
+		outerErr    error
+		expected    []string
+	}{
+		{
+			name:        "required",
+			propagation: PropagationRequired,
+			expected:    []string{"BEGIN", "outer", "inner", "COMMIT"},
+		},
+		{
+			name:        "required rollback only",
+			propagation: PropagationRequired,
+			innerErr:    errInner,
+			expectErr:   errInner,
+			outerErr:    ErrRollbackOnly,
+			expected:    []string{"BEGIN", "outer", "inner", "ROLLBACK"},
+		},
+		{
+			name:        "requires new",
+			propagation: PropagationRequiresNew,
+			expected:    []string{"BEGIN", "outer", "BEGIN", "inner", "COMMIT", "COMMIT"},
+		},
+		{
+			name:        "nested",
+			propagation: PropagationNested,
+			expected:    []string{"BEGIN", "outer", "SAVEPOINT vulcan_sp_1", "inner", "RELEASE SAVEPOINT vulcan_sp_1", "COMMIT"},
+		},
+		{
+			name:        "nested rollback",
+			propagation: PropagationNested,
+			innerErr:    errInner,
+			expectErr:   errInner,
+			expected:    []string{"BEGIN", "outer", "SAVEPOINT vulcan_sp_1", "inner", "ROLLBACK TO SAVEPOINT vulcan_sp_1", "COMMIT"},
+		},
+		{
+			name:        "supports",
+			propagation: PropagationSupports,
+			expected:    []string{"BEGIN", "outer", "inner", "COMMIT"},
+		},
+		{
+			name:        "never",
+			propagation: PropagationNever,
+			expectErr:   ErrTransactionExists,
+			expected:    []string{"BEGIN", "outer", "COMMIT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := setupTxTest(t)
+			err := Transactional(func(opts ...Option) error {
+				if err := execStmt("outer", opts...); err != nil {
+					return err
+				}
+
+				// 外层事务忽略内层事务的错误
+				err := Transactional(func(opts ...Option) error {
+					if err := execStmt("inner", opts...); err != nil {
+						return err
+					}
+					return tt.innerErr
+				}, appendOption(opts, WithPropagation(tt.propagation))...)
+				if tt.expectErr != nil && !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected error %v, got %v", tt.expectErr, err)
+				}
+
+				return nil
+			})
+			if !errors.Is(err, tt.outerErr) {
+				t.Errorf("expected error %v, got %v", tt.outerErr, err)
+			}
+
+			if got := state.Logs(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTransactionalWithoutOuterTransaction(t *testing.T) {
+	state := setupTxTest(t)
+	for _, p := range []Propagation{PropagationSupports, PropagationNever} {
+		err := Transactional(func(opts ...Option) error {
+			return execStmt("stmt", opts...)
+		}, WithPropagation(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, expected := state.Logs(), []string{"stmt", "stmt"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestTransactionalWithStartedTransaction(t *testing.T) {
+	state := setupTxTest(t)
+	tx, err := StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Transactional(func(opts ...Option) error {
+		return execStmt("inner", opts...)
+	}, WithTransaction(tx), WithPropagation(PropagationNested))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+
+	expected := []string{"BEGIN", "SAVEPOINT vulcan_sp_1", "inner", "RELEASE SAVEPOINT vulcan_sp_1", "COMMIT"}
+	if got := state.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestTransactionalExternalTransaction(t *testing.T) {
+	state := setupTxTest(t)
+	tx, err := StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	// 加入传入的事务, 由调用者提交或回滚
+	var hooksOk bool
+	err = Transactional(func(opts ...Option) error {
+		_, hooksOk = GetTxHooks(opts...)
+		return execStmt("outer", opts...)
+	}, WithTransaction(tx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hooksOk {
+		t.Fatal("expected no hooks for external transaction")
+	}
+
+	// 内层事务执行失败, 外层忽略错误时返回ErrRollbackOnly
+	errInner := errors.New("inner failed")
+	err = Transactional(func(opts ...Option) error {
+		_ = Transactional(func(opts ...Option) error {
+			return errInner
+		}, opts...)
+		return nil
+	}, WithTransaction(tx))
+	if !errors.Is(err, ErrRollbackOnly) {
+		t.Fatalf("expected ErrRollbackOnly, got %v", err)
+	}
+	if err := Transactional(func(opts ...Option) error {
+		return errInner
+	}, WithTransaction(tx)); err != errInner {
+		t.Fatalf("expected %v, got %v", errInner, err)
+	}
+
+	// 无法确定传入事务的数据源时, 不能开启新事务
+	err = Transactional(func(opts ...Option) error {
+		return execStmt("new", opts...)
+	}, WithTransaction(tx), WithPropagation(PropagationRequiresNew))
+	if err != ErrExternalTransaction {
+		t.Fatalf("expected ErrExternalTransaction, got %v", err)
+	}
+	err = Transactional(func(opts ...Option) error {
+		return execStmt("new", opts...)
+	}, WithTransaction(tx), WithDataSource(DefaultDataSource), WithPropagation(PropagationRequiresNew))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"BEGIN", "outer", "BEGIN", "new", "COMMIT"}
+	if got := state.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestTransactionalTxOptions(t *testing.T) {
+	state := setupTxTest(t)
+	txOptions := &TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+		Label:     "report",
+	}
+
+	var label string
+	err := Transactional(func(opts ...Option) error {
+		return execStmt("SELECT 1", appendOption(opts, WithInterceptors(func(option *ExecOption, next Handler) (any, error) {
+			label = option.TxOptions.Label
+			return next(option)
+		}))...)
+	}, WithTxOptions(txOptions))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if label != "report" {
+		t.Fatalf("expected tx label report, got %q", label)
+	}
+	expected := []string{"BEGIN Repeatable Read READ ONLY", "SELECT 1", "COMMIT"}
+	if got := state.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestTransactionalTimeout(t *testing.T) {
+	state := setupTxTest(t)
+	err := Transactional(func(opts ...Option) error {
+		time.Sleep(50 * time.Millisecond)
+		return execStmt("UPDATE t_user SET age = 1", opts...)
+	}, WithTxOptions(&TxOptions{Timeout: 10 * time.Millisecond}))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	for _, l := range state.Logs() {
+		if l == "UPDATE t_user SET age = 1" || l == "COMMIT" {
+			t.Fatalf("unexpected statement %s after timeout", l)
+		}
+	}
+}
+
+func TestStartTransactionTimeoutRelease(t *testing.T) {
+	state := setupTxTest(t)
+	for _, end := range []func(*sql.Tx) error{CommitTransaction, RollbackTransaction} {
+		tx, err := StartTransaction(WithTxOptions(&TxOptions{Timeout: time.Hour}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, ok := startedTxs.Load(tx)
+		if !ok {
+			t.Fatal("expected started transaction to be recorded")
+		}
+		txc, canceled := v.(*txContext), false
+		cancel := txc.cancel
+		txc.cancel = func() {
+			canceled = true
+			cancel()
+		}
+		if err := end(tx); err != nil {
+			t.Fatal(err)
+		}
+		// 事务结束时释放超时时间的context, 不需要等待超时
+		if _, ok := startedTxs.Load(tx); ok || !canceled {
+			t.Fatalf("expected started transaction to be released, canceled: %v", canceled)
+		}
+	}
+
+	expected := []string{"BEGIN", "COMMIT", "BEGIN", "ROLLBACK"}
+	if got := state.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestTransactionalWithRetry(t *testing.T) {
+	state := setupTxTest(t)
+	failures := 2
+	state.errFunc = func(query string) error {
+		if failures > 0 {
+			failures--
+			return &mysql.MySQLError{Number: mysqlErrLockDeadlock, Message: "Deadlock found when trying to get lock"}
+		}
+		return nil
+	}
+
+	var attempts []error
+	policy := &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		OnAttempt: func(attempt int, err error) {
+			attempts = append(attempts, err)
+		},
+	}
+	err := TransactionalWithRetry(policy, func(opts ...Option) error {
+		return execStmt("UPDATE t_user SET age = 1", opts...)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(attempts) != 3 || attempts[0] == nil || attempts[2] != nil {
+		t.Fatalf("unexpected attempts: %v", attempts)
+	}
+	expected := []string{
+		"BEGIN", "UPDATE t_user SET age = 1", "ROLLBACK",
+		"BEGIN", "UPDATE t_user SET age = 1", "ROLLBACK",
+		"BEGIN", "UPDATE t_user SET age = 1", "COMMIT",
+	}
+	if got := state.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	// 不可重试的错误直接返回
+	errUnknown := errors.New("unknown")
+	attempts = nil
+	err = TransactionalWithRetry(policy, func(opts ...Option) error {
+		return errUnknown
+	})
+	if err != errUnknown || len(attempts) != 1 {
+		t.Fatalf("expected 1 attempt with error %v, got %d attempts, error %v", errUnknown, len(attempts), err)
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		err       error
+		retryable bool
+	}{
+		{&mysql.MySQLError{Number: mysqlErrLockDeadlock}, true},
+		{&mysql.MySQLError{Number: mysqlErrLockWaitTimeout}, true},
+		{&pgError{code: pgErrDeadlockDetected}, true},
+		{&pgError{code: pgErrLockNotAvailable}, true},
+		{&QueryError{Kind: ErrDeadlock, Err: &mysql.MySQLError{Number: mysqlErrLockDeadlock}}, true},
+		// 语句执行超时重试也会超时
+		{&mysql.MySQLError{Number: mysqlErrQueryInterrupted}, false},
+		{&pgError{code: pgErrQueryCanceled}, false},
+		{&QueryError{Kind: ErrTimeout, Err: &pgError{code: pgErrQueryCanceled}}, false},
+		{errors.New("database is locked"), false},
+		{&statementTimeoutError{timeout: time.Second, err: errors.New("invalid connection")}, false},
+		{context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryableError(tt.err); got != tt.retryable {
+			t.Errorf("IsRetryableError(%v) = %v, expected %v", tt.err, got, tt.retryable)
+		}
+	}
+}
+
+func TestTransactionHooks(t *testing.T) {
+	setupTxTest(t)
+
+	var calls []string
+	record := func(name string) func() error {
+		return func() error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	register := func(opts []Option, prefix string) {
+		hooks, ok := GetTxHooks(opts...)
+		if !ok {
+			t.Fatal("expected transaction hooks")
+		}
+		hooks.OnCommit(record(prefix + "commit"))
+		hooks.OnRollback(record(prefix + "rollback"))
+		hooks.OnComplete(func(committed bool) error {
+			calls = append(calls, fmt.Sprintf("%scomplete %v", prefix, committed))
+			return nil
+		})
+	}
+
+	// 加入外层事务时, 回调函数在外层事务提交后执行
+	err := Transactional(func(opts ...Option) error {
+		register(opts, "outer ")
+		return Transactional(func(opts ...Option) error {
+			register(opts, "inner ")
+			if len(calls) != 0 {
+				t.Fatal("hooks must not run before commit")
+			}
+			return nil
+		}, opts...)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"outer commit", "outer complete true", "inner commit", "inner complete true"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("expected %v, got %v", expected, calls)
+	}
+
+	// 嵌套事务回滚到SAVEPOINT时, 其中注册的回调函数按照回滚执行
+	calls = nil
+	errTx := errors.New("tx error")
+	err = Transactional(func(opts ...Option) error {
+		register(opts, "outer ")
+		err := Transactional(func(opts ...Option) error {
+			register(opts, "nested ")
+			return errTx
+		}, append(opts, WithPropagation(PropagationNested))...)
+		if err != errTx {
+			t.Fatalf("expected %v, got %v", errTx, err)
+		}
+		return Transactional(func(opts ...Option) error {
+			register(opts, "released ")
+			return nil
+		}, append(opts, WithPropagation(PropagationNested))...)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"outer commit", "outer complete true", "nested rollback", "nested complete false", "released commit", "released complete true"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("expected %v, got %v", expected, calls)
+	}
+
+	calls = nil
+	err = Transactional(func(opts ...Option) error {
+		register(opts, "")
+		return errTx
+	})
+	if err != errTx {
+		t.Fatalf("expected %v, got %v", errTx, err)
+	}
+	expected = []string{"rollback", "complete false"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("expected %v, got %v", expected, calls)
+	}
+
+	// 回调函数的错误和panic会被返回, 后续的回调函数继续执行
+	calls = nil
+	errHook := errors.New("hook error")
+	err = Transactional(func(opts ...Option) error {
+		hooks, _ := GetTxHooks(opts...)
+		hooks.OnCommit(func() error { return errHook })
+		hooks.OnCommit(func() error { panic("boom") })
+		hooks.OnCommit(record("commit"))
+		return nil
+	})
+	var hookErr *TxHookError
+	if !errors.As(err, &hookErr) || !hookErr.Committed || len(hookErr.HookErrs) != 2 || hookErr.HookErrs[0] != errHook {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(calls, []string{"commit"}) {
+		t.Fatalf("expected remaining hooks to run, got %v", calls)
+	}
+
+	if _, ok := GetTxHooks(); ok {
+		t.Fatal("expected no hooks outside transaction")
+	}
+	tx, err := StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, ok := GetTxHooks(WithTransaction(tx)); ok {
+		t.Fatal("expected no hooks for external transaction")
+	}
+}
+
+func TestTransactionalContext(t *testing.T) {
+	state := setupTxTest(t)
+	db, _ := DataSource(DefaultDataSource)
+	otherDB, otherState := openFakeDB(t)
+
+	// 生成的mapper方法中Execer为mapper的db, Ctx为调用者传入的context
+	exec := func(ctx context.Context, execer Execer, sqlStmt string) error {
+		option := &ExecOption{SqlStmt: sqlStmt, Execer: execer, Ctx: ctx}
+		_, err := Invoke(option, func() (sql.Result, error) {
+			return option.Exec()
+		})
+		return err
+	}
+
+	err := TransactionalContext(context.Background(), func(ctx context.Context) error {
+		if err := exec(ctx, db, "mapper"); err != nil {
+			return err
+		}
+		if err := exec(ctx, nil, "no execer"); err != nil {
+			return err
+		}
+		// 其它数据库不使用context中的事务
+		if err := exec(ctx, otherDB, "other"); err != nil {
+			return err
+		}
+		// 嵌套调用加入context中的事务
+		return TransactionalContext(ctx, func(ctx context.Context) error {
+			return execStmt("nested", WithContext(ctx))
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"BEGIN", "mapper", "no execer", "nested", "COMMIT"}
+	if got := state.Logs(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	if got := otherState.Logs(); !reflect.DeepEqual(got, []string{"other"}) {
+		t.Fatalf("expected other db to run outside transaction, got %v", got)
+	}
+}
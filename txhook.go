@@ -0,0 +1,159 @@
+package vulcan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type txHookPhase int
+
+const (
+	txHookCommit txHookPhase = iota
+	txHookRollback
+	txHookComplete
+)
+
+type txHook struct {
+	phase      txHookPhase
+	fn         func(committed bool) error
+	rolledBack bool // 在已经回滚的SAVEPOINT中注册, 按照事务回滚执行
+}
+
+// TxHooks 事务回调函数注册表, 事务提交或回滚后按照注册顺序执行
+// 加入外层事务或者使用SAVEPOINT执行时, 回调函数在最外层事务结束后执行
+// 在回滚到SAVEPOINT的嵌套事务中注册的回调函数, 即使外层事务提交也按照回滚执行
+type TxHooks struct {
+	mu    sync.Mutex
+	hooks []txHook
+}
+
+// OnCommit 注册事务提交成功后执行的回调函数
+func (h *TxHooks) OnCommit(fn func() error) {
+	h.add(txHookCommit, func(bool) error {
+		return fn()
+	})
+}
+
+// OnRollback 注册事务回滚后执行的回调函数, 提交失败时也会执行
+func (h *TxHooks) OnRollback(fn func() error) {
+	h.add(txHookRollback, func(bool) error {
+		return fn()
+	})
+}
+
+// OnComplete 注册事务结束后执行的回调函数, committed表示事务是否提交成功
+func (h *TxHooks) OnComplete(fn func(committed bool) error) {
+	h.add(txHookComplete, fn)
+}
+
+func (h *TxHooks) add(phase txHookPhase, fn func(committed bool) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, txHook{phase: phase, fn: fn})
+}
+
+// 返回已注册的回调函数数量, 用于标记SAVEPOINT开始的位置
+func (h *TxHooks) mark() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.hooks)
+}
+
+// 将mark之后注册的回调函数标记为已回滚
+func (h *TxHooks) rollbackTo(mark int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := mark; i < len(h.hooks); i++ {
+		h.hooks[i].rolledBack = true
+	}
+}
+
+// 按照注册顺序执行回调函数, 回调函数中注册的回调函数也会被执行
+func (h *TxHooks) run(committed bool) []error {
+	var errs []error
+	for i := 0; ; i++ {
+		h.mu.Lock()
+		if i >= len(h.hooks) {
+			h.hooks = nil
+			h.mu.Unlock()
+			break
+		}
+		hook := h.hooks[i]
+		h.mu.Unlock()
+
+		hookCommitted := committed && !hook.rolledBack
+		if hook.phase == txHookCommit && !hookCommitted || hook.phase == txHookRollback && hookCommitted {
+			continue
+		}
+		if err := callTxHook(hook.fn, hookCommitted); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+func callTxHook(fn func(committed bool) error, committed bool) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("transaction hook panicked: %v", r)
+		}
+	}()
+
+	return fn(committed)
+}
+
+// TxHookError 事务结束后执行回调函数失败
+type TxHookError struct {
+	Committed bool    // 事务是否提交成功
+	Err       error   // 事务执行的错误, 提交成功时为nil
+	HookErrs  []error // 回调函数返回的错误以及发生的panic
+}
+
+func (e *TxHookError) Error() string {
+	builder := strings.Builder{}
+	if e.Committed {
+		builder.WriteString("transaction committed")
+	} else {
+		builder.WriteString("transaction rolled back")
+	}
+	if e.Err != nil {
+		builder.WriteString(": ")
+		builder.WriteString(e.Err.Error())
+	}
+	builder.WriteString(fmt.Sprintf(", %d hook(s) failed: ", len(e.HookErrs)))
+	for i, err := range e.HookErrs {
+		if i > 0 {
+			builder.WriteString("; ")
+		}
+		builder.WriteString(err.Error())
+	}
+
+	return builder.String()
+}
+
+// Unwrap 返回事务执行的错误, 可以使用errors.Is判断事务失败的原因
+func (e *TxHookError) Unwrap() error {
+	return e.Err
+}
+
+// GetTxHooks 获取opts中事务的回调函数注册表, 不在Transactional开启的事务中时返回false
+// 通过WithTransaction传入的事务由调用者提交, 无法注册回调函数
+func GetTxHooks(opts ...Option) (*TxHooks, bool) {
+	option := (&ExecOption{}).Apply(opts...)
+	txc := currentTransaction(option)
+	if txc == nil || txc.external {
+		return nil, false
+	}
+
+	return &txc.hooks, true
+}
+
+// TxHooksFromContext 获取context中事务的回调函数注册表
+func TxHooksFromContext(ctx context.Context) (*TxHooks, bool) {
+	return GetTxHooks(func(o *ExecOption) {
+		o.Ctx = ctx
+	})
+}
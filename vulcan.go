@@ -3,21 +3,18 @@ package vulcan
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
-var (
-	dbRef *sql.DB
-)
-
+// Execer 执行sql语句的接口, *sql.DB、*sql.Tx、*sql.Conn都实现了该接口
 type Execer interface {
-	Exec(query string, args ...any) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 
-	Query(query string, args ...any) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
 
-	QueryRow(query string, args ...any) *sql.Row
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
 type ExecOption struct {
@@ -26,22 +23,110 @@ type ExecOption struct {
 	Execer    Execer `name:"execer"`
 	Extension any    `name:"extension"`
 	Ctx       context.Context
+
+	DataSource     string          // 执行sql的数据源名称, 为空时使用Execer
+	Propagation    Propagation     // 事务传播行为, 仅在Transactional中生效
+	TxOptions      *TxOptions      // 事务选项, 在事务中执行时可以获取到开启事务时的选项
+	Dialect        Dialect         // 数据库方言, 未设置时使用数据源的方言
+	Static         bool            // 是否为静态sql, 开启预编译语句缓存时静态sql会使用预编译语句执行
+	Meta           *StatementMeta  // 生成代码时记录的sql元信息, 用于错误信息等
+	OptimisticLock *OptimisticLock // 乐观锁, 更新的行数为0时返回ErrOptimisticLock
+
+	execHandler      Handler  // 拦截器链最终调用的执行处理器
+	skipInterceptors []string // 本次执行跳过的拦截器
+	onlyInterceptors []string // 本次执行只使用的拦截器, 为nil时不限制
+	sensitiveArgs    []int    // 动态sql中通过Sensitive标记的参数位置
+	tenant           any      // 多租户拦截器改写sql时使用的租户, 缓存key中需要区分租户
+
+	timeout    time.Duration   // 本次执行的超时时间, 由WithTimeout指定或者执行时确定
+	timeoutCtx context.Context // 超时时间生效时创建的context, 用于判断执行失败是否由超时导致
+}
+
+// Context 获取执行sql时使用的context, 未设置时返回context.Background()
+func (e *ExecOption) Context() context.Context {
+	if e.Ctx == nil {
+		return context.Background()
+	}
+
+	return e.Ctx
 }
 
 func (e *ExecOption) Exec() (sql.Result, error) {
-	return e.Execer.Exec(e.SqlStmt, e.Args...)
+	if stmt, release := e.preparedStmt(); stmt != nil {
+		defer release()
+		return stmt.ExecContext(e.Context(), e.Args...)
+	}
+
+	return e.Execer.ExecContext(e.Context(), e.query(), e.Args...)
+}
+
+// ExecReturning 执行带有RETURNING子句的插入语句, 返回结果的LastInsertId为返回的第一个主键, RowsAffected为返回的行数
+func (e *ExecOption) ExecReturning() (sql.Result, error) {
+	rows, err := e.Select()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := &returningResult{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		if res.affected == 0 {
+			res.lastInsertId = id
+		}
+		res.affected++
+	}
+
+	return res, rows.Err()
+}
+
+type returningResult struct {
+	lastInsertId int64
+	affected     int64
+}
+
+func (r *returningResult) LastInsertId() (int64, error) {
+	return r.lastInsertId, nil
+}
+
+func (r *returningResult) RowsAffected() (int64, error) {
+	return r.affected, nil
 }
 
 func (e *ExecOption) Select() (*sql.Rows, error) {
-	return e.Execer.Query(e.SqlStmt, e.Args...)
+	if stmt, release := e.preparedStmt(); stmt != nil {
+		defer release()
+		return stmt.QueryContext(e.Context(), e.Args...)
+	}
+
+	return e.Execer.QueryContext(e.Context(), e.query(), e.Args...)
 }
 
 func (e *ExecOption) Get() *sql.Row {
-	return e.Execer.QueryRow(e.SqlStmt, e.Args...)
+	if stmt, release := e.preparedStmt(); stmt != nil {
+		defer release()
+		return stmt.QueryRowContext(e.Context(), e.Args...)
+	}
+
+	return e.Execer.QueryRowContext(e.Context(), e.query(), e.Args...)
 }
 
 type Option func(*ExecOption)
 
+// Apply 依次执行opts, 为nil的Option会被忽略
+func (e *ExecOption) Apply(opts ...Option) *ExecOption {
+	for _, opt := range opts {
+		if opt != nil {
+			opt(e)
+		}
+	}
+
+	return e
+}
+
 // WithTransaction 使用该函数来根据事务对象生成一个Option, 在执行sql操作时传入相应的方法中
 func WithTransaction(execer Execer) Option {
 	return func(o *ExecOption) {
@@ -49,6 +134,14 @@ func WithTransaction(execer Execer) Option {
 	}
 }
 
+// WithContext 指定执行sql时使用的context
+// context中存在TransactionalContext开启的事务时, 会在该事务中执行
+func WithContext(ctx context.Context) Option {
+	return func(o *ExecOption) {
+		o.Ctx = ctx
+	}
+}
+
 type interceptorKey struct{}
 
 func WithInterceptors(interceptor ...InterceptorHandler) Option {
@@ -62,44 +155,22 @@ func WithInterceptors(interceptor ...InterceptorHandler) Option {
 	}
 }
 
-// StartTransaction 使用该函数来开启一个事务, 返回Tx对象
-func StartTransaction() (*sql.Tx, error) {
-	return dbRef.Begin()
+// OpenMysql 连接mysql, 并注册为默认数据源
+func OpenMysql(dataSourceName string) (*sql.DB, error) {
+	return Open("mysql", dataSourceName, MySQL)
 }
 
-// Transactional 使用该函数来执行事务, 在回调函数中调用数据库操作语句
-func Transactional(fn func(opts ...Option) error) (err error) {
-	var tx *sql.Tx
-	tx, err = dbRef.Begin()
+// Open 连接数据库, 并使用指定的方言注册为默认数据源, 需要先导入对应的数据库驱动
+func Open(driverName, dataSourceName string, dialect Dialect) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dataSourceName)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer func() {
-		var e error
-		if r := recover(); r != nil || err != nil {
-			e = tx.Rollback()
-			if e == nil && r != nil {
-				e = fmt.Errorf("recovered from %v", r)
-			}
-		} else {
-			e = tx.Commit()
-		}
-		if e != nil {
-			err = e
-		}
-	}()
 
-	return fn(WithTransaction(tx))
-}
-
-// OpenMysql 连接mysql
-func OpenMysql(dataSourceName string) (*sql.DB, error) {
-	db, err := sql.Open("mysql", dataSourceName)
-	if err != nil {
+	Register(DefaultDataSource, db)
+	if err := SetDialect(DefaultDataSource, dialect); err != nil {
 		return nil, err
 	}
 
-	dbRef = db
-
 	return db, nil
 }
@@ -1,8 +1,11 @@
 package vulcan
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -15,15 +18,15 @@ func (d debugLogger) Debug(format string, args ...any) {
 type fakeExecer struct {
 }
 
-func (f fakeExecer) Query(query string, args ...any) (*sql.Rows, error) {
+func (f fakeExecer) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
 	return nil, nil
 }
 
-func (f fakeExecer) QueryRow(query string, args ...any) *sql.Row {
+func (f fakeExecer) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
 	return nil
 }
 
-func (f fakeExecer) Exec(query string, args ...any) (sql.Result, error) {
+func (f fakeExecer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
 	if len(args) > 0 {
 		arg := args[0]
 		switch a := arg.(type) {
@@ -37,12 +40,83 @@ func (f fakeExecer) Exec(query string, args ...any) (sql.Result, error) {
 func TestPaginationInterceptor(t *testing.T) {
 	SetupSqlDebugInterceptor(debugLogger{})
 	SetupPaginationInterceptor()
+	defer SetSqlDebugInterceptor(nil)
+	defer SetPaginationInterceptor(nil)
 
-	paging := NewPaging(1, 10).AddDescs("create_time")
-	paginationInterceptor.PreHandle(&ExecOption{
-		SqlStmt:   "SELECT username, password FROM t_user WHERE id > ?",
-		Execer:    fakeExecer{},
-		Extension: paging,
-	})
-	fmt.Printf("%+v\n", paging)
+	db, state := openFakeDB(t)
+	state.rowsFunc = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"count"}, [][]driver.Value{{int64(25)}}
+	}
+
+	tests := []struct {
+		dialect  Dialect
+		expected []string
+	}{
+		{
+			dialect: MySQL,
+			expected: []string{
+				"SELECT COUNT(*) FROM t_user WHERE id > ?",
+				"SELECT username, password FROM t_user WHERE id > ? ORDER BY create_time DESC, id ASC LIMIT 10 OFFSET 10",
+			},
+		},
+		{
+			dialect: Postgres,
+			expected: []string{
+				"SELECT COUNT(*) FROM t_user WHERE id > $1",
+				"SELECT username, password FROM t_user WHERE id > $1 ORDER BY create_time DESC, id ASC LIMIT 10 OFFSET 10",
+			},
+		},
+	}
+	for _, tt := range tests {
+		state.Reset()
+		paging := NewPaging(2, 10).AddDescs("create_time").AddAscs("id")
+		option := &ExecOption{
+			SqlStmt:   "SELECT username, password FROM t_user WHERE id > ?",
+			Args:      []any{1},
+			Execer:    db,
+			Extension: paging,
+			Dialect:   tt.dialect,
+		}
+		_, err := Invoke(option, func() (any, error) {
+			return nil, option.Get().Err()
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if paging.TotalCount() != 25 || paging.TotalPages() != 3 {
+			t.Fatalf("unexpected paging %+v", paging)
+		}
+		if got := state.Logs(); !reflect.DeepEqual(got, tt.expected) {
+			t.Fatalf("expected %v, got %v", tt.expected, got)
+		}
+	}
+}
+
+type ctxKey struct{}
+
+type ctxRecordExecer struct {
+	fakeExecer
+	ctx context.Context
+}
+
+func (c *ctxRecordExecer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	c.ctx = ctx
+	return nil, nil
+}
+
+func TestExecOptionContext(t *testing.T) {
+	execer := &ctxRecordExecer{}
+	option := &ExecOption{SqlStmt: "DELETE FROM t_user WHERE id = ?", Args: []any{1}, Execer: execer}
+	option.Exec()
+	if execer.ctx != context.Background() {
+		t.Fatalf("expected background context, got %v", execer.ctx)
+	}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
+	option.Ctx = ctx
+	option.Exec()
+	if execer.ctx.Value(ctxKey{}) != "v" {
+		t.Fatalf("context is not passed to execer")
+	}
 }